@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 	"tritontube/internal/proto"
 
@@ -30,23 +32,92 @@ func main() {
 
 	switch cmd {
 	case "add":
-		if len(os.Args) != 4 {
-			fmt.Println("Usage: add <server_address> <node_address>")
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: add <server_address> <node_address> [-vnodes N] [-weight W] [-zone Z] [-rack R] [-host H]")
 			os.Exit(1)
 		}
-		addNode(client, os.Args[3])
+		flags := flag.NewFlagSet("add", flag.ExitOnError)
+		vnodes := flags.Int("vnodes", 0, "Number of virtual tokens for the node (0 uses the server default)")
+		weight := flags.Float64("weight", 1, "Weight that scales the node's token count")
+		labels := labelFlags(flags)
+		flags.Parse(os.Args[4:])
+		addNode(client, os.Args[3], int32(*vnodes), *weight, labels)
 	case "remove":
 		if len(os.Args) != 4 {
 			fmt.Println("Usage: remove <server_address> <node_address>")
 			os.Exit(1)
 		}
 		removeNode(client, os.Args[3])
+	case "plan":
+		if len(os.Args) < 5 || (os.Args[3] != "add" && os.Args[3] != "remove") {
+			fmt.Println("Usage: plan <server_address> add|remove <node_address> [-vnodes N] [-weight W] [-zone Z] [-rack R] [-host H] [-files]")
+			os.Exit(1)
+		}
+		flags := flag.NewFlagSet("plan", flag.ExitOnError)
+		vnodes := flags.Int("vnodes", 0, "Number of virtual tokens for an added node (0 uses the server default)")
+		weight := flags.Float64("weight", 1, "Weight that scales an added node's token count")
+		labels := labelFlags(flags)
+		files := flags.Bool("files", false, "List every file that would move")
+		flags.Parse(os.Args[5:])
+		planChange(client, &proto.PlanMembershipChangeRequest{
+			Operation:    os.Args[3],
+			NodeAddress:  os.Args[4],
+			VirtualNodes: int32(*vnodes),
+			Weight:       *weight,
+			IncludeFiles: *files,
+			Labels:       labels,
+		})
+	case "drain":
+		if len(os.Args) != 4 {
+			fmt.Println("Usage: drain <server_address> <node_address>")
+			os.Exit(1)
+		}
+		drainNode(client, os.Args[3])
+	case "status":
+		if len(os.Args) != 4 {
+			fmt.Println("Usage: status <server_address> <job_id>")
+			os.Exit(1)
+		}
+		migrationStatus(client, os.Args[3])
+	case "retry":
+		if len(os.Args) != 4 {
+			fmt.Println("Usage: retry <server_address> <job_id>")
+			os.Exit(1)
+		}
+		retryMigration(client, os.Args[3])
 	case "list":
 		if len(os.Args) != 3 {
 			fmt.Println("Usage: list <server_address>")
 			os.Exit(1)
 		}
 		listNodes(client)
+	case "damaged":
+		if len(os.Args) != 3 {
+			fmt.Println("Usage: damaged <server_address>")
+			os.Exit(1)
+		}
+		listDamaged(client)
+	case "limits":
+		flags := flag.NewFlagSet("limits", flag.ExitOnError)
+		workers := flags.Int("workers", 0, "Number of files a migration moves at once")
+		bandwidth := flags.Int64("bandwidth", 0, "Cap on migration traffic in bytes per second, 0 for no cap")
+		flags.Parse(os.Args[3:])
+		if flags.NArg() != 0 {
+			fmt.Println("Usage: limits <server_address> [-workers N] [-bandwidth BYTES_PER_SEC]")
+			os.Exit(1)
+		}
+		// Only the flags given are changed.
+		req := &proto.SetMigrationLimitsRequest{}
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "workers":
+				n := int32(*workers)
+				req.Workers = &n
+			case "bandwidth":
+				req.BytesPerSecond = bandwidth
+			}
+		})
+		setLimits(client, req)
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		printUsageAndExit()
@@ -55,25 +126,47 @@ func main() {
 
 func printUsageAndExit() {
 	fmt.Println("Usage:")
-	fmt.Println("  add <server_address> <node_address>     - Add a node to the cluster")
+	fmt.Println("  add <server_address> <node_address> [-vnodes N] [-weight W] [-zone Z] [-rack R] [-host H]")
+	fmt.Println("                                          - Add a node to the cluster")
 	fmt.Println("  remove <server_address> <node_address>  - Remove a node from the cluster")
+	fmt.Println("  drain <server_address> <node_address>   - Move a node's files away before removing it")
 	fmt.Println("  list <server_address>                   - List all nodes in the cluster")
+	fmt.Println("  status <server_address> <job_id>        - Show the progress of a migration job")
+	fmt.Println("  retry <server_address> <job_id>         - Run a failed migration job again")
+	fmt.Println("  damaged <server_address>                - List corrupt files with no good copy left")
+	fmt.Println("  limits <server_address> [-workers N] [-bandwidth BYTES_PER_SEC]")
+	fmt.Println("                                          - Show or change how fast migrations move files")
+	fmt.Println("  plan <server_address> add|remove <node_address> [-vnodes N] [-weight W] [-zone Z] [-rack R] [-host H] [-files]")
+	fmt.Println("                                          - Show what a membership change would move")
 	os.Exit(1)
 }
 
-func addNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
+// labelFlags registers the flags that label a node with its failure domains.
+// The labels are filled in when flags is parsed.
+func labelFlags(flags *flag.FlagSet) *proto.NodeLabels {
+	labels := &proto.NodeLabels{}
+	flags.StringVar(&labels.Zone, "zone", "", "Zone the node is in")
+	flags.StringVar(&labels.Rack, "rack", "", "Rack the node is in")
+	flags.StringVar(&labels.Host, "host", "", "Host the node runs on")
+	return labels
+}
+
+func addNode(client proto.VideoContentAdminServiceClient, nodeAddr string, virtualNodes int32, weight float64, labels *proto.NodeLabels) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
 	response, err := client.AddNode(ctx, &proto.AddNodeRequest{
-		NodeAddress: nodeAddr,
+		NodeAddress:  nodeAddr,
+		VirtualNodes: virtualNodes,
+		Weight:       weight,
+		Labels:       labels,
 	})
 	if err != nil {
 		log.Fatalf("AddNode RPC failed: %v", err)
 	}
 
 	fmt.Printf("Successfully added node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
+	printJob(response.JobId)
 }
 
 func removeNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
@@ -88,7 +181,23 @@ func removeNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
 	}
 
 	fmt.Printf("Successfully removed node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
+	printJob(response.JobId)
+}
+
+func drainNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.DrainNode(ctx, &proto.DrainNodeRequest{
+		NodeAddress: nodeAddr,
+	})
+	if err != nil {
+		log.Fatalf("DrainNode RPC failed: %v", err)
+	}
+
+	fmt.Printf("Draining node: %s\n", nodeAddr)
+	printJob(response.JobId)
+	fmt.Println("Remove the node once the job has completed")
 }
 
 func listNodes(client proto.VideoContentAdminServiceClient) {
@@ -100,12 +209,160 @@ func listNodes(client proto.VideoContentAdminServiceClient) {
 		log.Fatalf("ListNodes RPC failed: %v", err)
 	}
 
-	fmt.Println("Storage cluster nodes:")
+	fmt.Printf("Storage cluster nodes (ring version %d):\n", response.RingVersion)
 	if len(response.Nodes) == 0 {
 		fmt.Println("  No nodes in cluster")
 	} else {
-		for _, node := range response.Nodes {
-			fmt.Printf("  - %s\n", node)
+		for _, node := range response.NodeInfo {
+			state := node.State
+			if node.Draining {
+				state += ", draining"
+			}
+			fmt.Printf("  - %s [%s] vnodes=%d weight=%g%s\n", node.Address, state, node.VirtualNodes, node.Weight, formatLabels(node.Labels))
+			if node.TotalBytes > 0 {
+				fmt.Printf("      files=%d used=%s free=%s of %s\n", node.FileCount, formatBytes(node.UsedBytes), formatBytes(node.FreeBytes), formatBytes(node.TotalBytes))
+			} else if node.FileCount > 0 || node.UsedBytes > 0 {
+				fmt.Printf("      files=%d used=%s\n", node.FileCount, formatBytes(node.UsedBytes))
+			} else if node.StatsError != "" {
+				fmt.Printf("      stats unavailable: %s\n", node.StatsError)
+			}
+		}
+	}
+}
+
+func listDamaged(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.ListDamagedFiles(ctx, &proto.ListDamagedFilesRequest{})
+	if err != nil {
+		log.Fatalf("ListDamagedFiles RPC failed: %v", err)
+	}
+
+	if len(response.Files) == 0 {
+		fmt.Println("No damaged files")
+		return
+	}
+	fmt.Println("Damaged files:")
+	for _, f := range response.Files {
+		detected := time.Unix(f.DetectedAt, 0).Format(time.RFC3339)
+		fmt.Printf("  - %s (quarantined on %s since %s)\n", f.Filename, strings.Join(f.Nodes, ", "), detected)
+	}
+}
+
+func setLimits(client proto.VideoContentAdminServiceClient, req *proto.SetMigrationLimitsRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.SetMigrationLimits(ctx, req)
+	if err != nil {
+		log.Fatalf("SetMigrationLimits RPC failed: %v", err)
+	}
+
+	bandwidth := "unlimited"
+	if response.BytesPerSecond > 0 {
+		bandwidth = formatBytes(response.BytesPerSecond) + "/s"
+	}
+	fmt.Printf("Migration limits: %d workers, bandwidth %s\n", response.Workers, bandwidth)
+}
+
+// formatLabels renders a node's labels as " zone=a rack=b host=c", leaving
+// out those that are unset.
+func formatLabels(labels *proto.NodeLabels) string {
+	var b strings.Builder
+	for _, label := range []struct{ name, value string }{
+		{"zone", labels.GetZone()},
+		{"rack", labels.GetRack()},
+		{"host", labels.GetHost()},
+	} {
+		if label.value != "" {
+			fmt.Fprintf(&b, " %s=%s", label.name, label.value)
+		}
+	}
+	return b.String()
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func printJob(jobId string) {
+	if jobId == "" {
+		fmt.Println("No migration needed")
+		return
+	}
+	fmt.Printf("Migration job started: %s\n", jobId)
+}
+
+func migrationStatus(client proto.VideoContentAdminServiceClient, jobId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.GetMigrationStatus(ctx, &proto.GetMigrationStatusRequest{
+		JobId: jobId,
+	})
+	if err != nil {
+		log.Fatalf("GetMigrationStatus RPC failed: %v", err)
+	}
+
+	fmt.Printf("Migration job %s (%s %s): %s\n", response.JobId, response.Operation, response.NodeAddress, response.State)
+	fmt.Printf("  Files moved:     %d\n", response.FilesMoved)
+	fmt.Printf("  Bytes moved:     %d\n", response.BytesMoved)
+	fmt.Printf("  Files remaining: %d\n", response.FilesRemaining)
+	if len(response.Errors) > 0 {
+		fmt.Println("  Errors:")
+		for _, e := range response.Errors {
+			fmt.Printf("    - %s\n", e)
+		}
+	}
+}
+
+func retryMigration(client proto.VideoContentAdminServiceClient, jobId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := client.RetryMigration(ctx, &proto.RetryMigrationRequest{
+		JobId: jobId,
+	})
+	if err != nil {
+		log.Fatalf("RetryMigration RPC failed: %v", err)
+	}
+
+	fmt.Printf("Retrying migration job: %s\n", jobId)
+}
+
+func planChange(client proto.VideoContentAdminServiceClient, req *proto.PlanMembershipChangeRequest) {
+	// Planning lists every file in the cluster, so allow more than the usual second.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	response, err := client.PlanMembershipChange(ctx, req)
+	if err != nil {
+		log.Fatalf("PlanMembershipChange RPC failed: %v", err)
+	}
+
+	fmt.Printf("Plan to %s node %s: %d files, %d bytes to move\n", req.Operation, req.NodeAddress, response.TotalFiles, response.TotalBytes)
+	if len(response.Transfers) == 0 {
+		fmt.Println("  No files would move")
+		return
+	}
+	for _, t := range response.Transfers {
+		fmt.Printf("  %s -> %s: %d files, %d bytes\n", t.Source, t.Destination, t.FileCount, t.Bytes)
+	}
+	if len(response.Files) > 0 {
+		fmt.Println("Files:")
+		for _, f := range response.Files {
+			fmt.Printf("  %s: %s -> %s (%d bytes)\n", f.Filename, f.Source, f.Destination, f.Bytes)
 		}
 	}
 }
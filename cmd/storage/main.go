@@ -4,15 +4,21 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"time"
+	"tritontube/internal/fsutil"
 	"tritontube/internal/proto"
 	"tritontube/internal/storage"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
 	host := flag.String("host", "localhost", "Host address for the server")
 	port := flag.Int("port", 8090, "Port number for the server")
+	fileMode := flag.String("file-mode", "0644", "Permissions of stored files, in octal")
+	scrubInterval := flag.Duration("scrub-interval", time.Hour, "How often to check stored files against their checksums (0 disables)")
 	flag.Parse()
 
 	// Validate arguments
@@ -26,6 +32,11 @@ func main() {
 		return
 	}
 	baseDir := flag.Arg(0)
+	mode, err := fsutil.ParseFileMode(*fileMode)
+	if err != nil {
+		fmt.Println("Error: Invalid file mode:", *fileMode)
+		return
+	}
 
 	fmt.Println("Starting storage server...")
 	fmt.Printf("Host: %s\n", *host)
@@ -39,9 +50,17 @@ func main() {
 		return
 	}
 	grpcServer := grpc.NewServer()
-	proto.RegisterStorageServiceServer(grpcServer, &storage.Server{
+	server := &storage.Server{
 		BaseDirectory: baseDir,
-	})
+		FileMode:      mode,
+	}
+	proto.RegisterStorageServiceServer(grpcServer, server)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("tritontube.StorageService", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if *scrubInterval > 0 {
+		go server.RunScrubber(*scrubInterval)
+	}
 	if err := grpcServer.Serve(lis); err != nil {
 		fmt.Println(err)
 	}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
+	"tritontube/internal/fsutil"
 	"tritontube/internal/proto"
 	"tritontube/internal/web"
 
@@ -31,6 +33,18 @@ func main() {
 	// Define flags
 	port := flag.Int("port", 8080, "Port number for the web server")
 	host := flag.String("host", "localhost", "Host address for the web server")
+	replicas := flag.Int("replicas", 1, "Number of storage nodes each file is replicated to (nw only)")
+	vnodes := flag.Int("vnodes", 1, "Default number of virtual ring tokens per storage node (nw only)")
+	healthInterval := flag.Duration("health-interval", 5*time.Second, "How often storage nodes are health checked, 0 to disable (nw only)")
+	ejectAfter := flag.Duration("eject-after", 0, "Eject storage nodes that stay down this long, 0 to never eject (nw only)")
+	antiEntropyInterval := flag.Duration("anti-entropy-interval", 10*time.Minute, "How often replicas are compared and repaired, 0 to disable (nw only)")
+	repairInterval := flag.Duration("repair-interval", time.Minute, "How often files quarantined by storage nodes are restored, 0 to disable (nw only)")
+	migrationWorkers := flag.Int("migration-workers", 4, "Number of files a migration moves at once (nw only)")
+	migrationBandwidth := flag.Int64("migration-bandwidth", 0, "Cap on migration traffic in bytes per second, 0 for no cap (nw only)")
+	placement := flag.String("placement", "ring", "How files are placed on storage nodes: ring, rendezvous, jump or bounded[:factor[:files|bytes]], optionally followed by +video or +video:stripes to keep each video on one or a few replica sets (nw only)")
+	loadInterval := flag.Duration("load-interval", time.Hour, "How often bounded placement measures the load on each ring range, 0 to disable (nw only)")
+	fileMode := flag.String("file-mode", "0644", "Permissions of stored content files, in octal (fs only)")
+	stateDir := flag.String("state-dir", "nw-state", "Directory for ring membership and migration state that survive restarts, empty to keep none (nw only)")
 
 	// Set custom usage message
 	flag.Usage = printUsage
@@ -83,7 +97,12 @@ func main() {
 	fmt.Println("Creating content service of type", contentServiceType, "with options", contentServiceOptions)
 	// TODO: Implement content service creation logic
 	if contentServiceType == "fs" {
-		fileSystem := &web.FSVideoContentService{}
+		mode, err := fsutil.ParseFileMode(*fileMode)
+		if err != nil {
+			fmt.Println("Error: Invalid file mode:", *fileMode)
+			return
+		}
+		fileSystem := &web.FSVideoContentService{FileMode: mode}
 		err = fileSystem.Initialize(contentServiceOptions)
 		if err != nil {
 			fmt.Printf("Failed to initialize FS: %v\n", err)
@@ -93,7 +112,24 @@ func main() {
 		addresses := strings.Split(contentServiceOptions, ",")
 		adminAddr := addresses[0]
 		storageAddrs := addresses[1:]
-		fileSystem, err := web.NewNetworkVideoContentService(adminAddr, storageAddrs)
+		placementStrategy, err := web.ParsePlacement(*placement)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fileSystem, err := web.NewNetworkVideoContentService(adminAddr, storageAddrs, web.NetworkConfig{
+			ReplicationFactor:   *replicas,
+			VirtualNodes:        *vnodes,
+			StateDir:            *stateDir,
+			HealthInterval:      *healthInterval,
+			EjectAfter:          *ejectAfter,
+			AntiEntropyInterval: *antiEntropyInterval,
+			RepairInterval:      *repairInterval,
+			MigrationWorkers:    *migrationWorkers,
+			MigrationBandwidth:  *migrationBandwidth,
+			Placement:           placementStrategy,
+			LoadInterval:        *loadInterval,
+		})
 		if err != nil {
 			fmt.Println(err)
 			return
@@ -0,0 +1,115 @@
+// Package filestream sends files to storage nodes over WriteFileStream, for
+// the web tier and for storage nodes pushing files to each other.
+package filestream
+
+import (
+	"io"
+	"tritontube/internal/proto"
+)
+
+// ChunkSize is the most file data sent in each WriteFileStream message.
+const ChunkSize = 1 << 20
+
+// Writer sends the data written to it over a WriteFileStream in chunks of at
+// most ChunkSize. The first message carries the header naming the file, so
+// the header also reaches the node for an empty file.
+type Writer struct {
+	stream proto.StorageService_WriteFileStreamClient
+	header *proto.WriteFileRequest
+	sent   int64
+	// done is set once the stream is finished, with err holding the node's
+	// verdict.
+	done bool
+	err  error
+}
+
+// NewWriter returns a Writer that sends header, with its Data replaced, as
+// the first message on stream.
+func NewWriter(stream proto.StorageService_WriteFileStreamClient, header *proto.WriteFileRequest) *Writer {
+	return &Writer{stream: stream, header: header}
+}
+
+// Write sends p. If the node fails the stream part way, Write returns the
+// node's status.
+func (w *Writer) Write(p []byte) (int, error) {
+	for written := 0; written < len(p); {
+		end := min(written+ChunkSize, len(p))
+		if err := w.send(p[written:end]); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return len(p), nil
+}
+
+// ReadFrom sends everything read from r, a full chunk at a time where r
+// allows.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, ChunkSize)
+	var read int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := w.send(buf[:n]); err != nil {
+				return read, err
+			}
+			read += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return read, nil
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+}
+
+// Sent returns the number of bytes sent so far.
+func (w *Writer) Sent() int64 {
+	return w.sent
+}
+
+// Close finishes the stream and returns the node's verdict on the file.
+func (w *Writer) Close() error {
+	if w.header != nil {
+		if err := w.send(nil); err != nil {
+			return err
+		}
+	}
+	return w.finish()
+}
+
+func (w *Writer) send(data []byte) error {
+	if w.done {
+		if w.err != nil {
+			return w.err
+		}
+		return io.ErrClosedPipe
+	}
+	req := &proto.WriteFileRequest{}
+	if w.header != nil {
+		req, w.header = w.header, nil
+	}
+	req.Data = data
+	if err := w.stream.Send(req); err != nil {
+		// io.EOF means the node has already failed the stream; its status
+		// is reported by CloseAndRecv.
+		if err == io.EOF {
+			if err := w.finish(); err != nil {
+				return err
+			}
+			return io.ErrShortWrite
+		}
+		return err
+	}
+	w.sent += int64(len(data))
+	return nil
+}
+
+func (w *Writer) finish() error {
+	if !w.done {
+		_, w.err = w.stream.CloseAndRecv()
+		w.done = true
+	}
+	return w.err
+}
@@ -0,0 +1,108 @@
+// Package fsutil writes files so that a crash never leaves a partially
+// written file in place of a complete one.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultFileMode is the permission given to files when none is configured.
+const DefaultFileMode os.FileMode = 0644
+
+// File is a file being written to a temporary name next to its final path.
+// It only appears at the final path once Commit succeeds.
+type File struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// Create starts writing the file at path with the given permissions.
+// Temporary names start with a dot so directory listings can skip them.
+func Create(path string, perm os.FileMode) (*File, error) {
+	if perm == 0 {
+		perm = DefaultFileMode
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &File{File: f, path: path}, nil
+}
+
+// Commit flushes the file to disk and moves it to its final path, then syncs
+// the directory so the rename itself survives a crash. With createOnly set,
+// Commit fails with an error matching os.ErrExist if a file is already at
+// the path, and leaves that file alone.
+func (f *File) Commit(createOnly bool) error {
+	defer f.Abort()
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if createOnly {
+		// Link, unlike rename, refuses to replace an existing file.
+		if err := os.Link(f.Name(), f.path); err != nil {
+			return err
+		}
+	} else if err := os.Rename(f.Name(), f.path); err != nil {
+		return err
+	}
+	f.committed = true
+	if createOnly {
+		os.Remove(f.Name())
+	}
+	return SyncDir(filepath.Dir(f.path))
+}
+
+// Abort discards the file unless it has been committed. It is safe to call
+// more than once.
+func (f *File) Abort() {
+	if f.committed {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFile atomically replaces the file at path with data, or with
+// createOnly set, creates it only if it does not exist yet.
+func WriteFile(path string, data []byte, perm os.FileMode, createOnly bool) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Commit(createOnly)
+}
+
+// SyncDir flushes a directory's entries to disk.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// ParseFileMode parses an octal permission such as "0644" from a flag.
+func ParseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	return os.FileMode(mode) & os.ModePerm, nil
+}
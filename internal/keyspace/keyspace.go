@@ -0,0 +1,77 @@
+// Package keyspace defines the hash space that the web tier's ring and the
+// storage nodes both place files in.
+package keyspace
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+)
+
+// Hash returns the position of s on the ring.
+func Hash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// Key returns the name a file is stored and placed under.
+func Key(videoId string, filename string) string {
+	return videoId + "/" + filename
+}
+
+// Split returns the video ID and filename of a key.
+func Split(key string) (string, string) {
+	videoId, filename, _ := strings.Cut(key, "/")
+	return videoId, filename
+}
+
+// Compare orders keys by video ID and then filename, the order in which
+// storage nodes list files. It differs from comparing the keys as strings
+// when one video ID is a prefix of another.
+func Compare(a string, b string) int {
+	aVideo, aFile := Split(a)
+	bVideo, bFile := Split(b)
+	if c := strings.Compare(aVideo, bVideo); c != 0 {
+		return c
+	}
+	return strings.Compare(aFile, bFile)
+}
+
+// InRange reports whether h lies in the ring range (start, end], which wraps
+// past zero when end <= start and is the whole ring when they are equal.
+func InRange(h uint64, start uint64, end uint64) bool {
+	if start == end {
+		return true
+	}
+	if start < end {
+		return h > start && h <= end
+	}
+	return h > start || h <= end
+}
+
+// leafWidth is the size of each of the n equal parts of (start, end].
+func leafWidth(start uint64, end uint64, n int) uint64 {
+	// end-start-1 is one less than the range size, which for the whole
+	// ring does not fit in a uint64.
+	return (end-start-1)/uint64(n) + 1
+}
+
+// Leaf returns which of the n equal parts of (start, end] holds h, which must
+// be in the range.
+func Leaf(h uint64, start uint64, end uint64, n int) int {
+	if n == 1 {
+		// The width of the whole ring as one leaf does not fit in a uint64.
+		return 0
+	}
+	return int((h - start - 1) / leafWidth(start, end, n))
+}
+
+// LeafRange returns the bounds of part i of (start, end] split into n parts.
+func LeafRange(i int, start uint64, end uint64, n int) (uint64, uint64) {
+	width := leafWidth(start, end, n)
+	leafStart := start + uint64(i)*width
+	if i == n-1 {
+		return leafStart, end
+	}
+	return leafStart, leafStart + width
+}
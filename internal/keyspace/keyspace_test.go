@@ -0,0 +1,94 @@
+package keyspace
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		h     uint64
+		start uint64
+		end   uint64
+		want  bool
+	}{
+		{"inside", 15, 10, 20, true},
+		{"at end", 20, 10, 20, true},
+		{"at start", 10, 10, 20, false},
+		{"before", 5, 10, 20, false},
+		{"after", 25, 10, 20, false},
+		{"wrapped high", math.MaxUint64, 20, 10, true},
+		{"wrapped zero", 0, 20, 10, true},
+		{"wrapped low", 5, 20, 10, true},
+		{"wrapped at end", 10, 20, 10, true},
+		{"wrapped at start", 20, 20, 10, false},
+		{"wrapped gap", 15, 20, 10, false},
+		{"whole ring", 15, 7, 7, true},
+		{"whole ring at bound", 7, 7, 7, true},
+		{"up to zero", 0, 10, 0, true},
+		{"from zero", 0, 0, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InRange(tt.h, tt.start, tt.end); got != tt.want {
+				t.Errorf("InRange(%d, %d, %d) = %v, want %v", tt.h, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaf(t *testing.T) {
+	tests := []struct {
+		name  string
+		h     uint64
+		start uint64
+		end   uint64
+		n     int
+		want  int
+	}{
+		{"first", 1, 0, 100, 4, 0},
+		{"end of first", 25, 0, 100, 4, 0},
+		{"start of second", 26, 0, 100, 4, 1},
+		{"last", 100, 0, 100, 4, 3},
+		{"single leaf", 100, 0, 100, 1, 0},
+		{"uneven last", 10, 0, 10, 3, 2},
+		{"wrapped before zero", math.MaxUint64, math.MaxUint64 - 10, 9, 2, 0},
+		{"wrapped after zero", 9, math.MaxUint64 - 10, 9, 2, 1},
+		{"whole ring first", 1, 0, 0, 2, 0},
+		{"whole ring middle", 1 << 63, 0, 0, 2, 0},
+		{"whole ring past middle", 1<<63 + 1, 0, 0, 2, 1},
+		{"whole ring last", 0, 0, 0, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Leaf(tt.h, tt.start, tt.end, tt.n); got != tt.want {
+				t.Errorf("Leaf(%d, %d, %d, %d) = %d, want %d", tt.h, tt.start, tt.end, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLeafRange checks that every hash falls in the bounds of the leaf Leaf
+// puts it in.
+func TestLeafRange(t *testing.T) {
+	ranges := [][2]uint64{{0, 100}, {math.MaxUint64 - 10, 9}, {0, 0}, {1 << 40, 1 << 40}, {5, 7}}
+	for _, r := range ranges {
+		for _, n := range []int{1, 2, 3, 16} {
+			for i := range 1000 {
+				h := Hash(string(rune(i)))
+				if !InRange(h, r[0], r[1]) {
+					continue
+				}
+				leaf := Leaf(h, r[0], r[1], n)
+				if leaf < 0 || leaf >= n {
+					t.Fatalf("Leaf(%d, %d, %d, %d) = %d, out of range", h, r[0], r[1], n, leaf)
+				}
+				start, end := LeafRange(leaf, r[0], r[1], n)
+				if !InRange(h, start, end) {
+					t.Errorf("hash %d in leaf %d of (%d, %d] split %d ways, outside (%d, %d]", h, leaf, r[0], r[1], n, start, end)
+				}
+			}
+		}
+	}
+}
@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.5.1
 // - protoc             v6.30.2
-// source: proto/admin.proto
+// source: admin.proto
 
 package proto
 
@@ -19,9 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	VideoContentAdminService_AddNode_FullMethodName    = "/tritontube.VideoContentAdminService/AddNode"
-	VideoContentAdminService_RemoveNode_FullMethodName = "/tritontube.VideoContentAdminService/RemoveNode"
-	VideoContentAdminService_ListNodes_FullMethodName  = "/tritontube.VideoContentAdminService/ListNodes"
+	VideoContentAdminService_AddNode_FullMethodName              = "/tritontube.VideoContentAdminService/AddNode"
+	VideoContentAdminService_RemoveNode_FullMethodName           = "/tritontube.VideoContentAdminService/RemoveNode"
+	VideoContentAdminService_ListNodes_FullMethodName            = "/tritontube.VideoContentAdminService/ListNodes"
+	VideoContentAdminService_GetMigrationStatus_FullMethodName   = "/tritontube.VideoContentAdminService/GetMigrationStatus"
+	VideoContentAdminService_PlanMembershipChange_FullMethodName = "/tritontube.VideoContentAdminService/PlanMembershipChange"
+	VideoContentAdminService_DrainNode_FullMethodName            = "/tritontube.VideoContentAdminService/DrainNode"
+	VideoContentAdminService_ListDamagedFiles_FullMethodName     = "/tritontube.VideoContentAdminService/ListDamagedFiles"
+	VideoContentAdminService_SetMigrationLimits_FullMethodName   = "/tritontube.VideoContentAdminService/SetMigrationLimits"
+	VideoContentAdminService_RetryMigration_FullMethodName       = "/tritontube.VideoContentAdminService/RetryMigration"
 )
 
 // VideoContentAdminServiceClient is the client API for VideoContentAdminService service.
@@ -31,6 +37,12 @@ type VideoContentAdminServiceClient interface {
 	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error)
 	RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error)
 	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error)
+	PlanMembershipChange(ctx context.Context, in *PlanMembershipChangeRequest, opts ...grpc.CallOption) (*PlanMembershipChangeResponse, error)
+	DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error)
+	ListDamagedFiles(ctx context.Context, in *ListDamagedFilesRequest, opts ...grpc.CallOption) (*ListDamagedFilesResponse, error)
+	SetMigrationLimits(ctx context.Context, in *SetMigrationLimitsRequest, opts ...grpc.CallOption) (*SetMigrationLimitsResponse, error)
+	RetryMigration(ctx context.Context, in *RetryMigrationRequest, opts ...grpc.CallOption) (*RetryMigrationResponse, error)
 }
 
 type videoContentAdminServiceClient struct {
@@ -71,6 +83,66 @@ func (c *videoContentAdminServiceClient) ListNodes(ctx context.Context, in *List
 	return out, nil
 }
 
+func (c *videoContentAdminServiceClient) GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMigrationStatusResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_GetMigrationStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) PlanMembershipChange(ctx context.Context, in *PlanMembershipChangeRequest, opts ...grpc.CallOption) (*PlanMembershipChangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlanMembershipChangeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_PlanMembershipChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_DrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) ListDamagedFiles(ctx context.Context, in *ListDamagedFilesRequest, opts ...grpc.CallOption) (*ListDamagedFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDamagedFilesResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_ListDamagedFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) SetMigrationLimits(ctx context.Context, in *SetMigrationLimitsRequest, opts ...grpc.CallOption) (*SetMigrationLimitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetMigrationLimitsResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_SetMigrationLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) RetryMigration(ctx context.Context, in *RetryMigrationRequest, opts ...grpc.CallOption) (*RetryMigrationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetryMigrationResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_RetryMigration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VideoContentAdminServiceServer is the server API for VideoContentAdminService service.
 // All implementations must embed UnimplementedVideoContentAdminServiceServer
 // for forward compatibility.
@@ -78,6 +150,12 @@ type VideoContentAdminServiceServer interface {
 	AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error)
 	RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error)
 	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error)
+	PlanMembershipChange(context.Context, *PlanMembershipChangeRequest) (*PlanMembershipChangeResponse, error)
+	DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error)
+	ListDamagedFiles(context.Context, *ListDamagedFilesRequest) (*ListDamagedFilesResponse, error)
+	SetMigrationLimits(context.Context, *SetMigrationLimitsRequest) (*SetMigrationLimitsResponse, error)
+	RetryMigration(context.Context, *RetryMigrationRequest) (*RetryMigrationResponse, error)
 	mustEmbedUnimplementedVideoContentAdminServiceServer()
 }
 
@@ -97,6 +175,24 @@ func (UnimplementedVideoContentAdminServiceServer) RemoveNode(context.Context, *
 func (UnimplementedVideoContentAdminServiceServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
 }
+func (UnimplementedVideoContentAdminServiceServer) GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMigrationStatus not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) PlanMembershipChange(context.Context, *PlanMembershipChangeRequest) (*PlanMembershipChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlanMembershipChange not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) ListDamagedFiles(context.Context, *ListDamagedFilesRequest) (*ListDamagedFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDamagedFiles not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) SetMigrationLimits(context.Context, *SetMigrationLimitsRequest) (*SetMigrationLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMigrationLimits not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) RetryMigration(context.Context, *RetryMigrationRequest) (*RetryMigrationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryMigration not implemented")
+}
 func (UnimplementedVideoContentAdminServiceServer) mustEmbedUnimplementedVideoContentAdminServiceServer() {
 }
 func (UnimplementedVideoContentAdminServiceServer) testEmbeddedByValue() {}
@@ -173,6 +269,114 @@ func _VideoContentAdminService_ListNodes_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VideoContentAdminService_GetMigrationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMigrationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).GetMigrationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_GetMigrationStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).GetMigrationStatus(ctx, req.(*GetMigrationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_PlanMembershipChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanMembershipChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).PlanMembershipChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_PlanMembershipChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).PlanMembershipChange(ctx, req.(*PlanMembershipChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_DrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_DrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, req.(*DrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_ListDamagedFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDamagedFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).ListDamagedFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_ListDamagedFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).ListDamagedFiles(ctx, req.(*ListDamagedFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_SetMigrationLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMigrationLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).SetMigrationLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_SetMigrationLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).SetMigrationLimits(ctx, req.(*SetMigrationLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_RetryMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryMigrationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).RetryMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_RetryMigration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).RetryMigration(ctx, req.(*RetryMigrationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VideoContentAdminService_ServiceDesc is the grpc.ServiceDesc for VideoContentAdminService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -192,7 +396,31 @@ var VideoContentAdminService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListNodes",
 			Handler:    _VideoContentAdminService_ListNodes_Handler,
 		},
+		{
+			MethodName: "GetMigrationStatus",
+			Handler:    _VideoContentAdminService_GetMigrationStatus_Handler,
+		},
+		{
+			MethodName: "PlanMembershipChange",
+			Handler:    _VideoContentAdminService_PlanMembershipChange_Handler,
+		},
+		{
+			MethodName: "DrainNode",
+			Handler:    _VideoContentAdminService_DrainNode_Handler,
+		},
+		{
+			MethodName: "ListDamagedFiles",
+			Handler:    _VideoContentAdminService_ListDamagedFiles_Handler,
+		},
+		{
+			MethodName: "SetMigrationLimits",
+			Handler:    _VideoContentAdminService_SetMigrationLimits_Handler,
+		},
+		{
+			MethodName: "RetryMigration",
+			Handler:    _VideoContentAdminService_RetryMigration_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/admin.proto",
+	Metadata: "admin.proto",
 }
@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.5.1
 // - protoc             v6.30.2
-// source: proto/storage.proto
+// source: storage.proto
 
 package proto
 
@@ -19,10 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	StorageService_ReadFile_FullMethodName   = "/tritontube.StorageService/ReadFile"
-	StorageService_WriteFile_FullMethodName  = "/tritontube.StorageService/WriteFile"
-	StorageService_DeleteFile_FullMethodName = "/tritontube.StorageService/DeleteFile"
-	StorageService_ListFiles_FullMethodName  = "/tritontube.StorageService/ListFiles"
+	StorageService_ReadFile_FullMethodName         = "/tritontube.StorageService/ReadFile"
+	StorageService_WriteFile_FullMethodName        = "/tritontube.StorageService/WriteFile"
+	StorageService_DeleteFile_FullMethodName       = "/tritontube.StorageService/DeleteFile"
+	StorageService_ListFiles_FullMethodName        = "/tritontube.StorageService/ListFiles"
+	StorageService_ReadFileStream_FullMethodName   = "/tritontube.StorageService/ReadFileStream"
+	StorageService_WriteFileStream_FullMethodName  = "/tritontube.StorageService/WriteFileStream"
+	StorageService_GetRangeDigest_FullMethodName   = "/tritontube.StorageService/GetRangeDigest"
+	StorageService_ListQuarantined_FullMethodName  = "/tritontube.StorageService/ListQuarantined"
+	StorageService_StatFile_FullMethodName         = "/tritontube.StorageService/StatFile"
+	StorageService_GetNodeStats_FullMethodName     = "/tritontube.StorageService/GetNodeStats"
+	StorageService_ListFilesInRange_FullMethodName = "/tritontube.StorageService/ListFilesInRange"
+	StorageService_TransferFiles_FullMethodName    = "/tritontube.StorageService/TransferFiles"
 )
 
 // StorageServiceClient is the client API for StorageService service.
@@ -32,7 +40,24 @@ type StorageServiceClient interface {
 	ReadFile(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (*ReadFileResponse, error)
 	WriteFile(ctx context.Context, in *WriteFileRequest, opts ...grpc.CallOption) (*Empty, error)
 	DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*Empty, error)
-	ListFiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListFilesResponse, error)
+	ListFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (*ListFilesResponse, error)
+	// Streaming variants move file data in fixed-size chunks. For
+	// WriteFileStream the first message carries video_id and filename.
+	ReadFileStream(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadFileResponse], error)
+	WriteFileStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteFileRequest, Empty], error)
+	GetRangeDigest(ctx context.Context, in *RangeDigestRequest, opts ...grpc.CallOption) (*RangeDigestResponse, error)
+	// Lists the files the scrubber found corrupt. A quarantined file is
+	// cleared once it is written again or deleted.
+	ListQuarantined(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListQuarantinedResponse, error)
+	// Describe a file or the node without reading any file data.
+	StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error)
+	GetNodeStats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatsResponse, error)
+	// Lists the files whose key hashes fall in a ring range, from an index
+	// kept by hash, so only the files in the range are visited.
+	ListFilesInRange(ctx context.Context, in *ListFilesInRangeRequest, opts ...grpc.CallOption) (*ListFilesResponse, error)
+	// Pushes files from this node straight to another storage node, which
+	// verifies them against their checksums.
+	TransferFiles(ctx context.Context, in *TransferFilesRequest, opts ...grpc.CallOption) (*TransferFilesResponse, error)
 }
 
 type storageServiceClient struct {
@@ -73,7 +98,7 @@ func (c *storageServiceClient) DeleteFile(ctx context.Context, in *DeleteFileReq
 	return out, nil
 }
 
-func (c *storageServiceClient) ListFiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListFilesResponse, error) {
+func (c *storageServiceClient) ListFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (*ListFilesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListFilesResponse)
 	err := c.cc.Invoke(ctx, StorageService_ListFiles_FullMethodName, in, out, cOpts...)
@@ -83,6 +108,98 @@ func (c *storageServiceClient) ListFiles(ctx context.Context, in *Empty, opts ..
 	return out, nil
 }
 
+func (c *storageServiceClient) ReadFileStream(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadFileResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageService_ServiceDesc.Streams[0], StorageService_ReadFileStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadFileRequest, ReadFileResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_ReadFileStreamClient = grpc.ServerStreamingClient[ReadFileResponse]
+
+func (c *storageServiceClient) WriteFileStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteFileRequest, Empty], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageService_ServiceDesc.Streams[1], StorageService_WriteFileStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WriteFileRequest, Empty]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_WriteFileStreamClient = grpc.ClientStreamingClient[WriteFileRequest, Empty]
+
+func (c *storageServiceClient) GetRangeDigest(ctx context.Context, in *RangeDigestRequest, opts ...grpc.CallOption) (*RangeDigestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RangeDigestResponse)
+	err := c.cc.Invoke(ctx, StorageService_GetRangeDigest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ListQuarantined(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ListQuarantinedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListQuarantinedResponse)
+	err := c.cc.Invoke(ctx, StorageService_ListQuarantined_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatFileResponse)
+	err := c.cc.Invoke(ctx, StorageService_StatFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) GetNodeStats(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeStatsResponse)
+	err := c.cc.Invoke(ctx, StorageService_GetNodeStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ListFilesInRange(ctx context.Context, in *ListFilesInRangeRequest, opts ...grpc.CallOption) (*ListFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFilesResponse)
+	err := c.cc.Invoke(ctx, StorageService_ListFilesInRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) TransferFiles(ctx context.Context, in *TransferFilesRequest, opts ...grpc.CallOption) (*TransferFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferFilesResponse)
+	err := c.cc.Invoke(ctx, StorageService_TransferFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StorageServiceServer is the server API for StorageService service.
 // All implementations must embed UnimplementedStorageServiceServer
 // for forward compatibility.
@@ -90,7 +207,24 @@ type StorageServiceServer interface {
 	ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error)
 	WriteFile(context.Context, *WriteFileRequest) (*Empty, error)
 	DeleteFile(context.Context, *DeleteFileRequest) (*Empty, error)
-	ListFiles(context.Context, *Empty) (*ListFilesResponse, error)
+	ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error)
+	// Streaming variants move file data in fixed-size chunks. For
+	// WriteFileStream the first message carries video_id and filename.
+	ReadFileStream(*ReadFileRequest, grpc.ServerStreamingServer[ReadFileResponse]) error
+	WriteFileStream(grpc.ClientStreamingServer[WriteFileRequest, Empty]) error
+	GetRangeDigest(context.Context, *RangeDigestRequest) (*RangeDigestResponse, error)
+	// Lists the files the scrubber found corrupt. A quarantined file is
+	// cleared once it is written again or deleted.
+	ListQuarantined(context.Context, *Empty) (*ListQuarantinedResponse, error)
+	// Describe a file or the node without reading any file data.
+	StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error)
+	GetNodeStats(context.Context, *Empty) (*NodeStatsResponse, error)
+	// Lists the files whose key hashes fall in a ring range, from an index
+	// kept by hash, so only the files in the range are visited.
+	ListFilesInRange(context.Context, *ListFilesInRangeRequest) (*ListFilesResponse, error)
+	// Pushes files from this node straight to another storage node, which
+	// verifies them against their checksums.
+	TransferFiles(context.Context, *TransferFilesRequest) (*TransferFilesResponse, error)
 	mustEmbedUnimplementedStorageServiceServer()
 }
 
@@ -110,9 +244,33 @@ func (UnimplementedStorageServiceServer) WriteFile(context.Context, *WriteFileRe
 func (UnimplementedStorageServiceServer) DeleteFile(context.Context, *DeleteFileRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
 }
-func (UnimplementedStorageServiceServer) ListFiles(context.Context, *Empty) (*ListFilesResponse, error) {
+func (UnimplementedStorageServiceServer) ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListFiles not implemented")
 }
+func (UnimplementedStorageServiceServer) ReadFileStream(*ReadFileRequest, grpc.ServerStreamingServer[ReadFileResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadFileStream not implemented")
+}
+func (UnimplementedStorageServiceServer) WriteFileStream(grpc.ClientStreamingServer[WriteFileRequest, Empty]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteFileStream not implemented")
+}
+func (UnimplementedStorageServiceServer) GetRangeDigest(context.Context, *RangeDigestRequest) (*RangeDigestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRangeDigest not implemented")
+}
+func (UnimplementedStorageServiceServer) ListQuarantined(context.Context, *Empty) (*ListQuarantinedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuarantined not implemented")
+}
+func (UnimplementedStorageServiceServer) StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatFile not implemented")
+}
+func (UnimplementedStorageServiceServer) GetNodeStats(context.Context, *Empty) (*NodeStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeStats not implemented")
+}
+func (UnimplementedStorageServiceServer) ListFilesInRange(context.Context, *ListFilesInRangeRequest) (*ListFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFilesInRange not implemented")
+}
+func (UnimplementedStorageServiceServer) TransferFiles(context.Context, *TransferFilesRequest) (*TransferFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferFiles not implemented")
+}
 func (UnimplementedStorageServiceServer) mustEmbedUnimplementedStorageServiceServer() {}
 func (UnimplementedStorageServiceServer) testEmbeddedByValue()                        {}
 
@@ -189,7 +347,7 @@ func _StorageService_DeleteFile_Handler(srv interface{}, ctx context.Context, de
 }
 
 func _StorageService_ListFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(ListFilesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -201,7 +359,133 @@ func _StorageService_ListFiles_Handler(srv interface{}, ctx context.Context, dec
 		FullMethod: StorageService_ListFiles_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StorageServiceServer).ListFiles(ctx, req.(*Empty))
+		return srv.(StorageServiceServer).ListFiles(ctx, req.(*ListFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ReadFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServiceServer).ReadFileStream(m, &grpc.GenericServerStream[ReadFileRequest, ReadFileResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_ReadFileStreamServer = grpc.ServerStreamingServer[ReadFileResponse]
+
+func _StorageService_WriteFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StorageServiceServer).WriteFileStream(&grpc.GenericServerStream[WriteFileRequest, Empty]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_WriteFileStreamServer = grpc.ClientStreamingServer[WriteFileRequest, Empty]
+
+func _StorageService_GetRangeDigest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeDigestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GetRangeDigest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_GetRangeDigest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GetRangeDigest(ctx, req.(*RangeDigestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ListQuarantined_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ListQuarantined(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_ListQuarantined_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ListQuarantined(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_StatFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).StatFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_StatFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).StatFile(ctx, req.(*StatFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_GetNodeStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GetNodeStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_GetNodeStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GetNodeStats(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ListFilesInRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFilesInRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ListFilesInRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_ListFilesInRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ListFilesInRange(ctx, req.(*ListFilesInRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_TransferFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).TransferFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_TransferFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).TransferFiles(ctx, req.(*TransferFilesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -229,7 +513,42 @@ var StorageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListFiles",
 			Handler:    _StorageService_ListFiles_Handler,
 		},
+		{
+			MethodName: "GetRangeDigest",
+			Handler:    _StorageService_GetRangeDigest_Handler,
+		},
+		{
+			MethodName: "ListQuarantined",
+			Handler:    _StorageService_ListQuarantined_Handler,
+		},
+		{
+			MethodName: "StatFile",
+			Handler:    _StorageService_StatFile_Handler,
+		},
+		{
+			MethodName: "GetNodeStats",
+			Handler:    _StorageService_GetNodeStats_Handler,
+		},
+		{
+			MethodName: "ListFilesInRange",
+			Handler:    _StorageService_ListFilesInRange_Handler,
+		},
+		{
+			MethodName: "TransferFiles",
+			Handler:    _StorageService_TransferFiles_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadFileStream",
+			Handler:       _StorageService_ReadFileStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteFileStream",
+			Handler:       _StorageService_WriteFileStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/storage.proto",
+	Metadata: "storage.proto",
 }
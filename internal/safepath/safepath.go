@@ -0,0 +1,53 @@
+// Package safepath checks the video IDs and filenames that name stored
+// files, so that no name taken from a request can reach outside the
+// directory it is joined to.
+package safepath
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLength is the longest name most filesystems accept for one path
+// element.
+const maxNameLength = 255
+
+// ErrInvalidName is matched by every error this package returns.
+var ErrInvalidName = errors.New("invalid name")
+
+// CheckName reports whether s can be used as a single path element: a video
+// ID or a filename. Names must be non-empty UTF-8 of at most 255 bytes, must
+// not contain path separators or control characters, and must not start with
+// a dot, which covers "." and ".." and keeps the storage nodes' own dot
+// files out of reach.
+func CheckName(s string) error {
+	switch {
+	case s == "":
+		return fmt.Errorf("%w: empty", ErrInvalidName)
+	case len(s) > maxNameLength:
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidName, maxNameLength)
+	case !utf8.ValidString(s):
+		return fmt.Errorf("%w: %q is not UTF-8", ErrInvalidName, s)
+	case strings.HasPrefix(s, "."):
+		return fmt.Errorf("%w: %q starts with a dot", ErrInvalidName, s)
+	case strings.ContainsAny(s, `/\`):
+		return fmt.Errorf("%w: %q contains a path separator", ErrInvalidName, s)
+	case strings.ContainsFunc(s, unicode.IsControl):
+		return fmt.Errorf("%w: %q contains a control character", ErrInvalidName, s)
+	}
+	return nil
+}
+
+// Check validates the video ID and filename of a stored file.
+func Check(videoId string, filename string) error {
+	if err := CheckName(videoId); err != nil {
+		return fmt.Errorf("video ID: %w", err)
+	}
+	if err := CheckName(filename); err != nil {
+		return fmt.Errorf("filename: %w", err)
+	}
+	return nil
+}
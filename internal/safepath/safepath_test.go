@@ -0,0 +1,76 @@
+package safepath
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"plain", "video1", true},
+		{"segment", "chunk-stream0-00001.m4s", true},
+		{"manifest", "manifest.mpd", true},
+		{"inner dot", "a.b", true},
+		{"unicode", "vidéo", true},
+		{"space", "my video", true},
+		{"max length", strings.Repeat("a", maxNameLength), true},
+		{"empty", "", false},
+		{"dot", ".", false},
+		{"dot dot", "..", false},
+		{"parent", "../x", false},
+		{"leading dot", ".checksums", false},
+		{"slash", "a/b", false},
+		{"trailing slash", "a/", false},
+		{"backslash", `a\b`, false},
+		{"NUL", "a\x00b", false},
+		{"newline", "a\nb", false},
+		{"escape", "a\x1bb", false},
+		{"DEL", "a\x7fb", false},
+		{"C1 control", "a\u0085b", false},
+		{"invalid UTF-8", "a\xffb", false},
+		{"truncated UTF-8", "vid\xc3", false},
+		{"over length", strings.Repeat("a", maxNameLength+1), false},
+		{"over length multibyte", strings.Repeat("é", maxNameLength/2+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckName(tt.input)
+			if tt.valid && err != nil {
+				t.Errorf("CheckName(%q) = %v, want nil", tt.input, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidName) {
+				t.Errorf("CheckName(%q) = %v, want ErrInvalidName", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		videoId  string
+		filename string
+		valid    bool
+	}{
+		{"valid", "video1", "manifest.mpd", true},
+		{"bad video ID", "..", "manifest.mpd", false},
+		{"bad filename", "video1", "../manifest.mpd", false},
+		{"empty filename", "video1", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.videoId, tt.filename)
+			if tt.valid && err != nil {
+				t.Errorf("Check(%q, %q) = %v, want nil", tt.videoId, tt.filename, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidName) {
+				t.Errorf("Check(%q, %q) = %v, want ErrInvalidName", tt.videoId, tt.filename, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"tritontube/internal/fsutil"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checksumDir holds the SHA-256 of every stored file, mirroring the
+// <videoId>/<filename> layout of BaseDirectory. walkFiles skips it.
+const checksumDir = ".checksums"
+
+// checksumMismatch is returned when file data does not match its checksum.
+func checksumMismatch(videoId string, filename string) error {
+	return status.Errorf(codes.DataLoss, "checksum mismatch for %s/%s", videoId, filename)
+}
+
+func (s *Server) checksumPath(videoId string, filename string) string {
+	return filepath.Join(s.BaseDirectory, checksumDir, videoId, filename)
+}
+
+// saveChecksum stores the checksum of a file written to the node.
+func (s *Server) saveChecksum(videoId string, filename string, sum []byte) error {
+	path := s.checksumPath(videoId, filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, []byte(hex.EncodeToString(sum)), s.FileMode, false)
+}
+
+// storedChecksum returns the checksum saved for a file, or nil if the file
+// was written before checksums were kept.
+func (s *Server) storedChecksum(videoId string, filename string) ([]byte, error) {
+	data, err := os.ReadFile(s.checksumPath(videoId, filename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(string(bytes.TrimSpace(data)))
+}
+
+// verifyChecksum checks a sum computed over file data against the expected
+// one. An empty expected checksum always passes.
+func verifyChecksum(videoId string, filename string, expected []byte, actual []byte) error {
+	if len(expected) > 0 && !bytes.Equal(expected, actual) {
+		return checksumMismatch(videoId, filename)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+// maxDigestDepth caps the number of leaves in a range digest at 2^16.
+const maxDigestDepth = 16
+
+// cachedChecksum is a file's SHA-256 along with the size and modification
+// time it was computed for.
+type cachedChecksum struct {
+	size    int64
+	modTime time.Time
+	sum     []byte
+}
+
+// checksum returns the SHA-256 of the named file: the one saved when it was
+// written or, for files written before checksums were kept, one computed from
+// its data and reused while its size and modification time are unchanged.
+func (s *Server) checksum(name string, path string, info os.FileInfo) ([]byte, error) {
+	videoId, filename := keyspace.Split(name)
+	if sum, err := s.storedChecksum(videoId, filename); err != nil || sum != nil {
+		return sum, err
+	}
+	s.checksumMu.Lock()
+	cached, ok := s.checksums[path]
+	s.checksumMu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	sum := h.Sum(nil)
+	s.checksumMu.Lock()
+	if s.checksums == nil {
+		s.checksums = make(map[string]cachedChecksum)
+	}
+	s.checksums[path] = cachedChecksum{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	s.checksumMu.Unlock()
+	return sum, nil
+}
+
+// GetRangeDigest returns a Merkle tree over the files in a ring range. Each
+// leaf hashes the names and checksums of the files in its part of the range,
+// so two replicas holding identical files return identical trees. Files and
+// their checksums come from the hash index rather than the disk.
+func (s *Server) GetRangeDigest(ctx context.Context, req *proto.RangeDigestRequest) (*proto.RangeDigestResponse, error) {
+	leafCount := 1 << min(req.Depth, maxDigestDepth)
+	leafFiles := make([][]*proto.FileDigest, leafCount)
+	s.indexMu.Lock()
+	if err := s.loadIndexLocked(); err != nil {
+		s.indexMu.Unlock()
+		return nil, err
+	}
+	first, second := s.indexRangeLocked(req.Start, req.End)
+	entries := slices.Concat(s.index[first[0]:first[1]], s.index[second[0]:second[1]])
+	s.indexMu.Unlock()
+
+	for _, entry := range entries {
+		sum, err := s.indexChecksum(entry)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted since the index was read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		leaf := keyspace.Leaf(entry.hash, req.Start, req.End, leafCount)
+		leafFiles[leaf] = append(leafFiles[leaf], &proto.FileDigest{Name: entry.name, Checksum: sum})
+	}
+
+	resp := &proto.RangeDigestResponse{Leaves: make([][]byte, leafCount)}
+	for i, files := range leafFiles {
+		slices.SortFunc(files, func(a, b *proto.FileDigest) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		h := sha256.New()
+		for _, f := range files {
+			h.Write([]byte(f.Name))
+			h.Write([]byte{0})
+			h.Write(f.Checksum)
+		}
+		resp.Leaves[i] = h.Sum(nil)
+		if req.IncludeFiles {
+			resp.Files = append(resp.Files, files...)
+		}
+	}
+	resp.Root = merkleRoot(resp.Leaves)
+	return resp, nil
+}
+
+// merkleRoot hashes nodes pairwise, level by level, down to a single root.
+func merkleRoot(nodes [][]byte) []byte {
+	for len(nodes) > 1 {
+		next := make([][]byte, 0, (len(nodes)+1)/2)
+		for i := 0; i < len(nodes); i += 2 {
+			pair := nodes[i]
+			if i+1 < len(nodes) {
+				pair = bytes.Join([][]byte{nodes[i], nodes[i+1]}, nil)
+			}
+			sum := sha256.Sum256(pair)
+			next = append(next, sum[:])
+		}
+		nodes = next
+	}
+	return nodes[0]
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// indexEntry is a stored file in the hash index.
+type indexEntry struct {
+	hash uint64
+	name string
+	size int64
+	// sum is the file's checksum, or nil until it is first needed.
+	sum []byte
+}
+
+func compareEntries(a indexEntry, b indexEntry) int {
+	if a.hash != b.hash {
+		if a.hash < b.hash {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.name, b.name)
+}
+
+// loadIndexLocked builds the hash index from the files on disk the first
+// time it is needed. Writes and deletes keep it current from then on. The
+// caller must hold s.indexMu.
+func (s *Server) loadIndexLocked() error {
+	if s.indexLoaded {
+		return nil
+	}
+	var entries []indexEntry
+	var bytes int64
+	err := s.walkFiles(func(name string, path string, info os.FileInfo) error {
+		entries = append(entries, indexEntry{hash: keyspace.Hash(name), name: name, size: info.Size()})
+		bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(entries, compareEntries)
+	s.index = entries
+	s.indexBytes = bytes
+	s.indexLoaded = true
+	return nil
+}
+
+// indexPut records a file written with the given size and checksum.
+func (s *Server) indexPut(videoId string, filename string, size int64, sum []byte) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if !s.indexLoaded {
+		return
+	}
+	entry := indexEntry{hash: keyspace.Hash(keyspace.Key(videoId, filename)), name: keyspace.Key(videoId, filename), size: size, sum: sum}
+	i, found := slices.BinarySearchFunc(s.index, entry, compareEntries)
+	if found {
+		s.indexBytes += size - s.index[i].size
+		s.index[i] = entry
+		return
+	}
+	s.indexBytes += size
+	s.index = slices.Insert(s.index, i, entry)
+}
+
+// indexDelete drops a file that was deleted or quarantined.
+func (s *Server) indexDelete(videoId string, filename string) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if !s.indexLoaded {
+		return
+	}
+	entry := indexEntry{hash: keyspace.Hash(keyspace.Key(videoId, filename)), name: keyspace.Key(videoId, filename)}
+	if i, found := slices.BinarySearchFunc(s.index, entry, compareEntries); found {
+		s.indexBytes -= s.index[i].size
+		s.index = slices.Delete(s.index, i, i+1)
+	}
+}
+
+// indexAfter returns the position of the first entry after the one with the
+// given hash and name.
+func (s *Server) indexAfter(hash uint64, name string) int {
+	i, found := slices.BinarySearchFunc(s.index, indexEntry{hash: hash, name: name}, compareEntries)
+	if found {
+		i += 1
+	}
+	return i
+}
+
+// indexAfterHash returns the position of the first entry whose hash is
+// greater than hash.
+func (s *Server) indexAfterHash(hash uint64) int {
+	return sort.Search(len(s.index), func(i int) bool {
+		return s.index[i].hash > hash
+	})
+}
+
+// indexRangeLocked returns the positions of the entries in the ring range
+// (start, end] as at most two runs of the index: the entries after start,
+// and, when the range wraps, the entries from the beginning up to end. The
+// caller must hold s.indexMu.
+func (s *Server) indexRangeLocked(start uint64, end uint64) ([2]int, [2]int) {
+	first := [2]int{s.indexAfterHash(start), len(s.index)}
+	var second [2]int
+	if start < end {
+		first[1] = s.indexAfterHash(end)
+	} else {
+		second = [2]int{0, s.indexAfterHash(end)}
+	}
+	return first, second
+}
+
+// indexChecksum returns the checksum of the file entry, a copy of an index
+// entry, and caches it in the index if the entry is still there without one.
+// It must be called without s.indexMu held, since computing a checksum reads
+// the whole file.
+func (s *Server) indexChecksum(entry indexEntry) ([]byte, error) {
+	if entry.sum != nil {
+		return entry.sum, nil
+	}
+	path := filepath.Join(s.BaseDirectory, filepath.FromSlash(entry.name))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := s.checksum(entry.name, path, info)
+	if err != nil {
+		return nil, err
+	}
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	// A write since then stored the new file's checksum, which is kept.
+	if i, found := slices.BinarySearchFunc(s.index, entry, compareEntries); found && s.index[i].sum == nil {
+		s.index[i].sum = sum
+	}
+	return sum, nil
+}
+
+// pageToken identifies the last entry of a page.
+func pageToken(entry indexEntry) string {
+	return strconv.FormatUint(entry.hash, 16) + ":" + entry.name
+}
+
+func parsePageToken(token string) (uint64, string, error) {
+	hash, name, ok := strings.Cut(token, ":")
+	h, err := strconv.ParseUint(hash, 16, 64)
+	if !ok || err != nil {
+		return 0, "", status.Errorf(codes.InvalidArgument, "invalid page token %q", token)
+	}
+	return h, name, nil
+}
+
+func (s *Server) ListFilesInRange(ctx context.Context, req *proto.ListFilesInRangeRequest) (*proto.ListFilesResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if err := s.loadIndexLocked(); err != nil {
+		return nil, err
+	}
+
+	start := req.GetStart()
+	first, second := s.indexRangeLocked(start, req.GetEnd())
+	if req.GetPageToken() != "" {
+		hash, name, err := parsePageToken(req.GetPageToken())
+		if err != nil {
+			return nil, err
+		}
+		if hash > start {
+			first[0] = max(first[0], s.indexAfter(hash, name))
+		} else {
+			first[0] = first[1]
+			second[0] = max(second[0], s.indexAfter(hash, name))
+		}
+	}
+
+	resp := &proto.ListFilesResponse{Filenames: make([]string, 0), Files: make([]*proto.FileInfo, 0)}
+	var last indexEntry
+	for _, run := range [][2]int{first, second} {
+		for i := run[0]; i < run[1]; i++ {
+			if len(resp.Files) == pageSize {
+				// There is at least one more file, so there is another page.
+				resp.NextPageToken = pageToken(last)
+				return resp, nil
+			}
+			entry := s.index[i]
+			last = entry
+			resp.Filenames = append(resp.Filenames, entry.name)
+			resp.Files = append(resp.Files, &proto.FileInfo{Name: entry.name, Size: entry.size})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+// listRange pages through ListFilesInRange with the given page size and
+// returns every name listed.
+func listRange(t *testing.T, s *Server, start uint64, end uint64, pageSize int32) []string {
+	t.Helper()
+	var names []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 1000 {
+			t.Fatalf("listing (%d, %d] does not end", start, end)
+		}
+		resp, err := s.ListFilesInRange(context.Background(), &proto.ListFilesInRangeRequest{Start: start, End: end, PageSize: pageSize, PageToken: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Filenames) > int(pageSize) {
+			t.Fatalf("page of %d files, want at most %d", len(resp.Filenames), pageSize)
+		}
+		names = append(names, resp.Filenames...)
+		if resp.NextPageToken == "" {
+			return names
+		}
+		token = resp.NextPageToken
+	}
+}
+
+// wantRange returns the names in (start, end] in the order
+// ListFilesInRange lists them: by distance past start, then by name.
+func wantRange(names []string, start uint64, end uint64) []string {
+	var want []string
+	for _, name := range names {
+		if keyspace.InRange(keyspace.Hash(name), start, end) {
+			want = append(want, name)
+		}
+	}
+	slices.SortFunc(want, func(a, b string) int {
+		if c := cmp.Compare(keyspace.Hash(a)-start-1, keyspace.Hash(b)-start-1); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return want
+}
+
+func TestListFilesInRange(t *testing.T) {
+	s := &Server{BaseDirectory: t.TempDir()}
+	var names []string
+	write := func(videoId string, filename string) {
+		t.Helper()
+		_, err := s.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Data: []byte(filename)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, keyspace.Key(videoId, filename))
+	}
+	for i := range 50 {
+		write(fmt.Sprintf("v%d", i%7), fmt.Sprintf("f%d", i))
+	}
+
+	hashes := make([]uint64, len(names))
+	for i, name := range names {
+		hashes[i] = keyspace.Hash(name)
+	}
+	slices.Sort(hashes)
+	low, mid, high := hashes[5], hashes[25], hashes[45]
+	ranges := []struct {
+		name       string
+		start, end uint64
+	}{
+		{"plain", low, high},
+		{"wrapping", high, low},
+		{"wrapping at zero", mid, 0},
+		{"from zero", 0, mid},
+		{"whole ring", mid, mid},
+		{"whole ring from zero", 0, 0},
+		{"whole ring from max", math.MaxUint64, math.MaxUint64},
+		{"single file", hashes[9], hashes[10]},
+		{"empty", hashes[9], hashes[9] + 1},
+	}
+	check := func(t *testing.T) {
+		for _, tt := range ranges {
+			t.Run(tt.name, func(t *testing.T) {
+				want := wantRange(names, tt.start, tt.end)
+				for _, pageSize := range []int32{1, 3, 7, 1000} {
+					got := listRange(t, s, tt.start, tt.end, pageSize)
+					if !slices.Equal(got, want) {
+						t.Errorf("page size %d: listed %v, want %v", pageSize, got, want)
+					}
+				}
+			})
+		}
+	}
+	check(t)
+
+	// Writes and deletes after the index is built keep it current.
+	write("v1", "late")
+	write("v100", "late")
+	if _, err := s.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: "v0", Filename: "f0"}); err != nil {
+		t.Fatal(err)
+	}
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return name == "v0/f0"
+	})
+	t.Run("after changes", check)
+}
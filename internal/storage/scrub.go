@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+// quarantineDir holds files the scrubber found corrupt, in the same
+// <videoId>/<filename> layout as BaseDirectory.
+const quarantineDir = ".quarantine"
+
+// RunScrubber checks every stored file against its checksum each interval.
+func (s *Server) RunScrubber(interval time.Duration) {
+	for range time.Tick(interval) {
+		checked, quarantined, err := s.Scrub()
+		if err != nil {
+			log.Printf("Scrub: %v\n", err)
+		}
+		if quarantined > 0 {
+			log.Printf("Scrub: checked %d files, quarantined %d\n", checked, quarantined)
+		}
+	}
+}
+
+// Scrub re-reads every stored file and compares it with the checksum saved
+// when it was written. Corrupt files are moved into quarantine, so the node
+// no longer serves or lists them. Files that predate checksums have one saved
+// now. It returns the number of files checked and quarantined.
+func (s *Server) Scrub() (int, int, error) {
+	var names []string
+	err := s.walkFiles(func(name string, path string, info os.FileInfo) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	checked, quarantined := 0, 0
+	for _, name := range names {
+		videoId, filename := keyspace.Split(name)
+		corrupt, err := s.scrubFile(videoId, filename)
+		if os.IsNotExist(err) {
+			// Deleted since the walk.
+			continue
+		}
+		if err != nil {
+			return checked, quarantined, err
+		}
+		checked += 1
+		if corrupt {
+			quarantined += 1
+		}
+	}
+	return checked, quarantined, nil
+}
+
+// scrubFile checks one file and quarantines it if it is corrupt.
+func (s *Server) scrubFile(videoId string, filename string) (bool, error) {
+	lock := s.fileLock(videoId, filename)
+	lock.Lock()
+	defer lock.Unlock()
+	f, err := os.Open(filepath.Join(s.BaseDirectory, videoId, filename))
+	if err != nil {
+		return false, err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return false, err
+	}
+	sum := h.Sum(nil)
+	expected, err := s.storedChecksum(videoId, filename)
+	if err != nil {
+		return false, err
+	}
+	if expected == nil {
+		return false, s.saveChecksum(videoId, filename, sum)
+	}
+	if verifyChecksum(videoId, filename, expected, sum) == nil {
+		return false, nil
+	}
+	log.Printf("Scrub: %s/%s is corrupt, quarantining it\n", videoId, filename)
+	return true, s.quarantine(videoId, filename)
+}
+
+func (s *Server) quarantinePath(videoId string, filename string) string {
+	return filepath.Join(s.BaseDirectory, quarantineDir, videoId, filename)
+}
+
+// quarantine moves a corrupt file out of BaseDirectory along with its
+// checksum. The caller must hold the file's lock.
+func (s *Server) quarantine(videoId string, filename string) error {
+	path := s.quarantinePath(videoId, filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(s.BaseDirectory, videoId, filename), path); err != nil {
+		return err
+	}
+	s.indexDelete(videoId, filename)
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return err
+	}
+	err := os.Remove(s.checksumPath(videoId, filename))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// clearQuarantine drops the quarantined copy of a file once it has been
+// replaced or deleted. The caller must hold the file's lock.
+func (s *Server) clearQuarantine(videoId string, filename string) error {
+	err := os.Remove(s.quarantinePath(videoId, filename))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// fileLock returns the lock serializing writes, deletes and scrubs of a file.
+// Locks are striped, so unrelated files may share one.
+func (s *Server) fileLock(videoId string, filename string) *sync.Mutex {
+	return &s.fileLocks[keyspace.Hash(keyspace.Key(videoId, filename))%uint64(len(s.fileLocks))]
+}
+
+func (s *Server) ListQuarantined(ctx context.Context, req *proto.Empty) (*proto.ListQuarantinedResponse, error) {
+	root := filepath.Join(s.BaseDirectory, quarantineDir)
+	dirs, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return &proto.ListQuarantinedResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.ListQuarantinedResponse{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil {
+				return nil, err
+			}
+			resp.Files = append(resp.Files, &proto.QuarantinedFile{
+				Name:          keyspace.Key(dir.Name(), file.Name()),
+				QuarantinedAt: info.ModTime().Unix(),
+			})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+func (s *Server) StatFile(ctx context.Context, req *proto.StatFileRequest) (*proto.StatFileResponse, error) {
+	if err := checkName(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.BaseDirectory, req.GetVideoId(), req.GetFilename())
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fileError(err)
+	}
+	sum, err := s.checksum(keyspace.Key(req.GetVideoId(), req.GetFilename()), path, info)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.StatFileResponse{Size: info.Size(), ModifiedAt: info.ModTime().Unix(), Checksum: sum}, nil
+}
+
+func (s *Server) GetNodeStats(ctx context.Context, req *proto.Empty) (*proto.NodeStatsResponse, error) {
+	// The counts come from the hash index, which is kept current by every
+	// write and delete, so stats never walk the files.
+	s.indexMu.Lock()
+	err := s.loadIndexLocked()
+	resp := &proto.NodeStatsResponse{FileCount: int64(len(s.index)), UsedBytes: s.indexBytes}
+	s.indexMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// The base directory may not exist before the first write; its parent
+	// is on the same filesystem as far as capacity planning is concerned.
+	dir := s.BaseDirectory
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = filepath.Dir(dir)
+	}
+	resp.TotalBytes, resp.FreeBytes, err = diskUsage(dir)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -4,65 +4,393 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"tritontube/internal/fsutil"
+	"tritontube/internal/keyspace"
 	"tritontube/internal/proto"
+	"tritontube/internal/safepath"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// chunkSize is the amount of file data carried by each streamed message.
+const chunkSize = 1 << 20
+
 // Implement a network video content service (server)
 type Server struct {
 	proto.UnimplementedStorageServiceServer
 	BaseDirectory string
+	// FileMode is the permission stored files are created with;
+	// fsutil.DefaultFileMode when zero.
+	FileMode os.FileMode
+
+	checksumMu sync.Mutex
+	checksums  map[string]cachedChecksum
+	fileLocks  [64]sync.Mutex
+
+	// index holds every stored file ordered by key hash once indexLoaded
+	// is set, and indexBytes their total size.
+	indexMu     sync.Mutex
+	index       []indexEntry
+	indexBytes  int64
+	indexLoaded bool
+
+	peersMu sync.Mutex
+	peers   map[string]proto.StorageServiceClient
 }
 
-func (s *Server) ReadFile(ctx context.Context, req *proto.ReadFileRequest) (*proto.ReadFileResponse, error) {
+// fileError converts a filesystem error into a gRPC status so that clients
+// can tell a missing or already existing file apart from other failures.
+func fileError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return err
+}
+
+// checkName rejects names that would resolve outside BaseDirectory.
+func checkName(videoId string, filename string) error {
+	if err := safepath.Check(videoId, filename); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// byteRange returns the start and end of the part of a file of the given
+// size that req asks for.
+func byteRange(req *proto.ReadFileRequest, size int64) (int64, int64, error) {
+	if req.GetOffset() < 0 || req.GetLength() < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "offset and length must not be negative")
+	}
+	start := min(req.GetOffset(), size)
+	end := size
+	if req.GetLength() > 0 && req.GetLength() < size-start {
+		end = start + req.GetLength()
+	}
+	return start, end, nil
+}
+
+// openRange opens the file req names and returns its header response, which
+// describes the whole file, along with the range to send. Partial ranges
+// cannot be checked against the file's checksum; the scrubber covers them.
+func (s *Server) openRange(req *proto.ReadFileRequest) (*os.File, *proto.ReadFileResponse, int64, int64, error) {
+	if err := checkName(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, nil, 0, 0, err
+	}
 	path := filepath.Join(s.BaseDirectory, req.GetVideoId(), req.GetFilename())
-	data, err := os.ReadFile(path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, 0, fileError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, 0, 0, err
+	}
+	start, end, err := byteRange(req, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, 0, 0, err
+	}
+	sum, err := s.checksum(keyspace.Key(req.GetVideoId(), req.GetFilename()), path, info)
+	if err != nil {
+		f.Close()
+		return nil, nil, 0, 0, err
+	}
+	header := &proto.ReadFileResponse{Checksum: sum, Size: info.Size(), ModifiedAt: info.ModTime().Unix()}
+	return f, header, start, end, nil
+}
+
+func (s *Server) ReadFile(ctx context.Context, req *proto.ReadFileRequest) (*proto.ReadFileResponse, error) {
+	f, resp, start, end, err := s.openRange(req)
 	if err != nil {
 		return nil, err
 	}
-	return &proto.ReadFileResponse{Data: data}, nil
+	defer f.Close()
+	resp.Data = make([]byte, end-start)
+	if _, err := f.ReadAt(resp.Data, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if end-start == resp.Size {
+		sum := sha256.Sum256(resp.Data)
+		if err := verifyChecksum(req.GetVideoId(), req.GetFilename(), resp.Checksum, sum[:]); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 func (s *Server) WriteFile(ctx context.Context, req *proto.WriteFileRequest) (*proto.Empty, error) {
+	if err := checkName(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(req.Data)
+	if err := verifyChecksum(req.GetVideoId(), req.GetFilename(), req.Checksum, sum[:]); err != nil {
+		return nil, err
+	}
 	dir := filepath.Join(s.BaseDirectory, req.GetVideoId())
 	if err := os.MkdirAll(dir, 0777); err != nil {
 		return nil, err
 	}
+	lock := s.fileLock(req.GetVideoId(), req.GetFilename())
+	lock.Lock()
+	defer lock.Unlock()
 	path := filepath.Join(dir, req.Filename)
-	if err := os.WriteFile(path, req.Data, 0777); err != nil {
+	if err := fsutil.WriteFile(path, req.Data, s.FileMode, req.CreateOnly); err != nil {
+		return nil, fileError(err)
+	}
+	if err := s.saveChecksum(req.GetVideoId(), req.GetFilename(), sum[:]); err != nil {
 		return nil, err
 	}
+	if err := s.clearQuarantine(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, err
+	}
+	s.indexPut(req.GetVideoId(), req.GetFilename(), int64(len(req.Data)), sum[:])
 	return &proto.Empty{}, nil
 }
 
 func (s *Server) DeleteFile(ctx context.Context, req *proto.DeleteFileRequest) (*proto.Empty, error) {
-	path := filepath.Join(s.BaseDirectory, req.GetVideoId(), req.GetFilename())
-	if err := os.Remove(path); err != nil {
+	if err := checkName(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, err
+	}
+	lock := s.fileLock(req.GetVideoId(), req.GetFilename())
+	lock.Lock()
+	defer lock.Unlock()
+	if err := s.deleteFileLocked(req.GetVideoId(), req.GetFilename()); err != nil {
 		return nil, err
 	}
 	return &proto.Empty{}, nil
 }
 
-func (s *Server) ListFiles(ctx context.Context, req *proto.Empty) (*proto.ListFilesResponse, error) {
-	dirs, err := os.ReadDir(s.BaseDirectory)
-	filenames := make([]string, 0)
+// deleteFileLocked removes a file along with its checksum and any
+// quarantined copy. The caller must hold the file's lock.
+func (s *Server) deleteFileLocked(videoId string, filename string) error {
+	if err := s.clearQuarantine(videoId, filename); err != nil {
+		return err
+	}
+	path := filepath.Join(s.BaseDirectory, videoId, filename)
+	if err := os.Remove(path); err != nil {
+		return fileError(err)
+	}
+	s.indexDelete(videoId, filename)
+	s.checksumMu.Lock()
+	delete(s.checksums, path)
+	s.checksumMu.Unlock()
+	err := os.Remove(s.checksumPath(videoId, filename))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Page sizes for ListFiles.
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+)
+
+// errStopWalk stops walkFilesFrom without it reporting an error.
+var errStopWalk = errors.New("stop walk")
+
+func (s *Server) ListFiles(ctx context.Context, req *proto.ListFilesRequest) (*proto.ListFilesResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	resp := &proto.ListFilesResponse{Filenames: make([]string, 0), Files: make([]*proto.FileInfo, 0)}
+	err := s.walkFilesFrom(req.GetPrefix(), req.GetPageToken(), func(name string, path string, info os.FileInfo) error {
+		if len(resp.Filenames) == pageSize {
+			// There is at least one more file, so there is another page.
+			resp.NextPageToken = resp.Filenames[pageSize-1]
+			return errStopWalk
+		}
+		resp.Filenames = append(resp.Filenames, name)
+		resp.Files = append(resp.Files, &proto.FileInfo{Name: name, Size: info.Size()})
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return resp, nil
+}
+
+// walkFiles calls fn for every stored file with its "<videoId>/<filename>"
+// name, its path on disk and its info.
+func (s *Server) walkFiles(fn func(name string, path string, info os.FileInfo) error) error {
+	return s.walkFilesFrom("", "", fn)
+}
+
+// walkFilesFrom is walkFiles limited to the files whose names start with
+// prefix and that come after the name after in keyspace.Compare order, which
+// is the order files are visited in. fn may return errStopWalk to end the
+// walk early.
+func (s *Server) walkFilesFrom(prefix string, after string, fn func(name string, path string, info os.FileInfo) error) error {
+	dirs, err := os.ReadDir(s.BaseDirectory)
+	if os.IsNotExist(err) {
+		// Nothing has been written to this node yet.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	afterVideo, _ := keyspace.Split(after)
 	for _, dir := range dirs {
-		if dir.IsDir() {
-			subDir := filepath.Join(s.BaseDirectory, dir.Name())
-			files, err := os.ReadDir(subDir)
+		// Dot directories hold the node's own bookkeeping, not videos.
+		if !dir.IsDir() || strings.HasPrefix(dir.Name(), ".") {
+			continue
+		}
+		if after != "" && dir.Name() < afterVideo {
+			continue
+		}
+		if !strings.HasPrefix(dir.Name(), prefix) && !strings.HasPrefix(prefix, dir.Name()+"/") {
+			continue
+		}
+		subDir := filepath.Join(s.BaseDirectory, dir.Name())
+		files, err := os.ReadDir(subDir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			// Dot files are writes still in progress.
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			name := keyspace.Key(dir.Name(), f.Name())
+			if !strings.HasPrefix(name, prefix) || (after != "" && keyspace.Compare(name, after) <= 0) {
+				continue
+			}
+			info, err := f.Info()
 			if err != nil {
-				return nil, err
+				return err
 			}
-			for _, f := range files {
-				if !f.IsDir() {
-					filenames = append(filenames, dir.Name()+"/"+f.Name())
+			if err := fn(name, filepath.Join(subDir, f.Name()), info); err != nil {
+				if err == errStopWalk {
+					return nil
 				}
+				return err
 			}
 		}
 	}
-	return &proto.ListFilesResponse{Filenames: filenames}, nil
+	return nil
+}
+
+func (s *Server) ReadFileStream(req *proto.ReadFileRequest, stream proto.StorageService_ReadFileStreamServer) error {
+	f, resp, start, end, err := s.openRange(req)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// Whole files are verified as they are sent; on a mismatch the stream
+	// ends with a DataLoss error after the last chunk, and the client
+	// discards it.
+	whole := end-start == resp.Size
+	expected := resp.Checksum
+	h := sha256.New()
+	r := io.NewSectionReader(f, start, end-start)
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || (resp != nil && err == io.EOF) {
+			if resp == nil {
+				resp = &proto.ReadFileResponse{}
+			}
+			resp.Data = buf[:n]
+			h.Write(buf[:n])
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			resp = nil
+		}
+		if err == io.EOF {
+			if !whole {
+				return nil
+			}
+			return verifyChecksum(req.GetVideoId(), req.GetFilename(), expected, h.Sum(nil))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) WriteFileStream(stream proto.StorageService_WriteFileStreamServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	videoId := req.GetVideoId()
+	filename := req.GetFilename()
+	if err := checkName(videoId, filename); err != nil {
+		return err
+	}
+	dir := filepath.Join(s.BaseDirectory, videoId)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	createOnly := req.GetCreateOnly()
+	f, err := fsutil.Create(filepath.Join(dir, filename), s.FileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	h := sha256.New()
+	var expected []byte
+	var size int64
+	for {
+		if len(req.GetChecksum()) > 0 {
+			expected = req.GetChecksum()
+		}
+		h.Write(req.GetData())
+		if _, err := f.Write(req.GetData()); err != nil {
+			return err
+		}
+		size += int64(len(req.GetData()))
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sum := h.Sum(nil)
+	if err := verifyChecksum(videoId, filename, expected, sum); err != nil {
+		return err
+	}
+	if err := s.commitFile(f, videoId, filename, size, sum, createOnly); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&proto.Empty{})
+}
+
+// commitFile puts a fully received file in place under its file lock. The
+// lock is not held while the file is received, so a slow sender does not hold
+// up other writes of files sharing the lock.
+func (s *Server) commitFile(f *fsutil.File, videoId string, filename string, size int64, sum []byte, createOnly bool) error {
+	lock := s.fileLock(videoId, filename)
+	lock.Lock()
+	defer lock.Unlock()
+	if err := f.Commit(createOnly); err != nil {
+		return fileError(err)
+	}
+	if err := s.saveChecksum(videoId, filename, sum); err != nil {
+		return err
+	}
+	if err := s.clearQuarantine(videoId, filename); err != nil {
+		return err
+	}
+	s.indexPut(videoId, filename, size, sum)
+	return nil
 }
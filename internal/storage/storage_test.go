@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidNames are video ID and filename pairs that every RPC must reject.
+var invalidNames = []struct {
+	name     string
+	videoId  string
+	filename string
+}{
+	{"parent video ID", "..", "manifest.mpd"},
+	{"parent filename", "video", ".."},
+	{"escaping filename", "video", "../../etc/passwd"},
+	{"nested filename", "video", "a/b"},
+	{"backslash", "video", `a\b`},
+	{"dot file", "video", ".checksums"},
+	{"bookkeeping directory", ".quarantine", "manifest.mpd"},
+	{"empty video ID", "", "manifest.mpd"},
+	{"empty filename", "video", ""},
+	{"NUL", "video", "a\x00b"},
+	{"invalid UTF-8", "video", "a\xffb"},
+}
+
+// writeStream is a WriteFileStream server stream that receives reqs.
+type writeStream struct {
+	grpc.ServerStream
+	reqs []*proto.WriteFileRequest
+}
+
+func (w *writeStream) Recv() (*proto.WriteFileRequest, error) {
+	if len(w.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := w.reqs[0]
+	w.reqs = w.reqs[1:]
+	return req, nil
+}
+
+func (w *writeStream) SendAndClose(*proto.Empty) error {
+	return nil
+}
+
+func wantInvalidArgument(t *testing.T, rpc string, err error) {
+	t.Helper()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("%s: got %v, want InvalidArgument", rpc, err)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	base := t.TempDir()
+	s := &Server{BaseDirectory: filepath.Join(base, "node")}
+	ctx := context.Background()
+	for _, tt := range invalidNames {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.WriteFile(ctx, &proto.WriteFileRequest{VideoId: tt.videoId, Filename: tt.filename, Data: []byte("x")})
+			wantInvalidArgument(t, "WriteFile", err)
+			err = s.WriteFileStream(&writeStream{reqs: []*proto.WriteFileRequest{{VideoId: tt.videoId, Filename: tt.filename, Data: []byte("x")}}})
+			wantInvalidArgument(t, "WriteFileStream", err)
+			_, err = s.ReadFile(ctx, &proto.ReadFileRequest{VideoId: tt.videoId, Filename: tt.filename})
+			wantInvalidArgument(t, "ReadFile", err)
+			err = s.ReadFileStream(&proto.ReadFileRequest{VideoId: tt.videoId, Filename: tt.filename}, nil)
+			wantInvalidArgument(t, "ReadFileStream", err)
+			_, err = s.StatFile(ctx, &proto.StatFileRequest{VideoId: tt.videoId, Filename: tt.filename})
+			wantInvalidArgument(t, "StatFile", err)
+			_, err = s.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: tt.videoId, Filename: tt.filename})
+			wantInvalidArgument(t, "DeleteFile", err)
+			_, err = s.TransferFiles(ctx, &proto.TransferFilesRequest{
+				Destination: "localhost:1",
+				Filenames:   []string{"video/manifest.mpd", tt.videoId + "/" + tt.filename},
+			})
+			wantInvalidArgument(t, "TransferFiles", err)
+		})
+	}
+	// Nothing may have been written anywhere, inside the base directory or
+	// out of it.
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("unexpected %s written", entry.Name())
+	}
+}
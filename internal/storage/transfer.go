@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"tritontube/internal/filestream"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// peer returns a client for the storage node at addr, reusing connections
+// across transfers.
+func (s *Server) peer(addr string) (proto.StorageServiceClient, error) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	if client, ok := s.peers[addr]; ok {
+		return client, nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	if s.peers == nil {
+		s.peers = make(map[string]proto.StorageServiceClient)
+	}
+	client := proto.NewStorageServiceClient(conn)
+	s.peers[addr] = client
+	return client, nil
+}
+
+// TransferFiles pushes files to another node one at a time. A failure on one
+// file is reported in its result and does not stop the others, but an invalid
+// name fails the whole request before anything is sent.
+func (s *Server) TransferFiles(ctx context.Context, req *proto.TransferFilesRequest) (*proto.TransferFilesResponse, error) {
+	for _, name := range req.GetFilenames() {
+		if err := checkName(keyspace.Split(name)); err != nil {
+			return nil, err
+		}
+	}
+	dst, err := s.peer(req.GetDestination())
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.TransferFilesResponse{}
+	for _, name := range req.GetFilenames() {
+		result := &proto.TransferResult{Name: name}
+		result.Bytes, err = s.transferFile(ctx, dst, name, req.GetDeleteAfter())
+		if err != nil {
+			result.Error = err.Error()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// transferFile pushes one file to dst and, if deleteAfter is set, deletes it
+// here once dst has accepted it.
+func (s *Server) transferFile(ctx context.Context, dst proto.StorageServiceClient, name string, deleteAfter bool) (int64, error) {
+	videoId, filename := keyspace.Split(name)
+	// The file lock is not held while pushing: dst may be this node, or be
+	// pushing to this node in turn, and its write takes the lock.
+	size, sent, sum, err := s.pushFile(ctx, dst, videoId, filename)
+	if err != nil {
+		return 0, err
+	}
+	if !deleteAfter {
+		return size, nil
+	}
+	lock := s.fileLock(videoId, filename)
+	lock.Lock()
+	defer lock.Unlock()
+	// Only the copy dst has may be deleted; a file replaced since, even by
+	// the push itself when dst is this node, is kept.
+	path := filepath.Join(s.BaseDirectory, videoId, filename)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return size, nil
+	}
+	if err != nil {
+		return size, fmt.Errorf("delete after transfer: %w", err)
+	}
+	current, err := s.checksum(name, path, info)
+	if err != nil {
+		return size, fmt.Errorf("delete after transfer: %w", err)
+	}
+	if !os.SameFile(sent, info) || !bytes.Equal(current, sum) {
+		return size, status.Errorf(codes.Aborted, "%s changed during transfer; not deleted", name)
+	}
+	if err := s.deleteFileLocked(videoId, filename); err != nil {
+		return size, fmt.Errorf("delete after transfer: %w", err)
+	}
+	return size, nil
+}
+
+// pushFile streams a file to dst along with its checksum, so dst rejects it
+// unless it arrives intact. It returns the number of bytes sent and the file
+// info and checksum of the file they were read from.
+func (s *Server) pushFile(ctx context.Context, dst proto.StorageServiceClient, videoId string, filename string) (int64, os.FileInfo, []byte, error) {
+	path := filepath.Join(s.BaseDirectory, videoId, filename)
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, nil, fileError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	sum, err := s.checksum(keyspace.Key(videoId, filename), path, info)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	// Cancelling on return aborts the write stream if reading fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := dst.WriteFileStream(ctx)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	w := filestream.NewWriter(stream, &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Checksum: sum})
+	if _, err := io.Copy(w, f); err != nil {
+		return w.Sent(), nil, nil, err
+	}
+	return w.Sent(), info, sum, w.Close()
+}
@@ -0,0 +1,223 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+// antiEntropyDepth is the depth of the digest trees compared between
+// replicas; each ring range is split into 2^antiEntropyDepth leaves.
+const antiEntropyDepth = 4
+
+// runAntiEntropy repairs divergent replicas every interval.
+func (n *NetworkVideoContentService) runAntiEntropy(interval time.Duration) {
+	for range time.Tick(interval) {
+		repaired, err := n.antiEntropyPass()
+		if err != nil {
+			log.Printf("Anti-entropy: %v\n", err)
+		}
+		if repaired > 0 {
+			log.Printf("Anti-entropy: repaired %d file copies\n", repaired)
+		}
+	}
+}
+
+// antiEntropyPass compares the replicas of every ring range using Merkle
+// trees, or file by file if the placement is not range based, and copies
+// only the files that are missing or differ. Passes are
+// skipped while a migration runs, since files are expected to be out of place
+// then. It returns the number of file copies made.
+func (n *NetworkVideoContentService) antiEntropyPass() (int, error) {
+	if n.replicationFactor < 2 || !n.migrationMu.TryLock() {
+		return 0, nil
+	}
+	defer n.migrationMu.Unlock()
+	n.mu.RLock()
+	r := n.ring.clone()
+	n.mu.RUnlock()
+
+	if !r.placement.rangeBased() {
+		return n.repairScattered(r)
+	}
+	repaired := 0
+	var errs []string
+	for i, token := range r.tokens {
+		start := r.tokens[(i+len(r.tokens)-1)%len(r.tokens)]
+		replicas := slices.DeleteFunc(r.replicas(token, n.replicationFactor), isDown)
+		if len(replicas) < 2 {
+			continue
+		}
+		count, err := repairRange(replicas, start, token)
+		repaired += count
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return repaired, fmt.Errorf("%d ranges failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return repaired, nil
+}
+
+// isDown reports whether node failed its recent health checks.
+func isDown(node *Node) bool {
+	health, _ := node.healthState()
+	return health == healthDown
+}
+
+// digestFilesPerPart is about how many files repairScattered has a node list
+// in one digest, which keeps responses well under gRPC's 4 MB message limit.
+const digestFilesPerPart = 10000
+
+// repairScattered compares replicas file by file, for placements that give
+// neighbouring keys different replicas so ranges cannot be compared as a
+// whole. The ring is split into at least 2^antiEntropyDepth parts, more on
+// nodes holding many files, each listed with checksums from every node, and
+// every file is brought in line across its replicas on r.
+func (n *NetworkVideoContentService) repairScattered(r *hashRing) (int, error) {
+	nodes := slices.DeleteFunc(r.nodes(), isDown)
+	repaired := 0
+	var errs []string
+	parts := 1 << antiEntropyDepth
+	for _, node := range nodes {
+		stats, err := node.client.GetNodeStats(context.Background(), &proto.Empty{})
+		if err != nil {
+			return 0, fmt.Errorf("stats from %s: %w", node.address, err)
+		}
+		for int64(parts)*digestFilesPerPart < stats.FileCount {
+			parts *= 2
+		}
+	}
+	for part := range parts {
+		start, end := keyspace.LeafRange(part, 0, 0, parts)
+		checksums := make(map[*Node]map[string][]byte, len(nodes))
+		var names []string
+		for _, node := range nodes {
+			resp, err := node.client.GetRangeDigest(context.Background(), &proto.RangeDigestRequest{Start: start, End: end, IncludeFiles: true})
+			if err != nil {
+				return repaired, fmt.Errorf("digest from %s: %w", node.address, err)
+			}
+			checksums[node] = make(map[string][]byte)
+			for _, f := range resp.Files {
+				checksums[node][f.Name] = f.Checksum
+				names = append(names, f.Name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range slices.Compact(names) {
+			replicas := slices.DeleteFunc(r.keyReplicas(name, n.replicationFactor), func(node *Node) bool {
+				return checksums[node] == nil
+			})
+			replicaChecksums := make([]map[string][]byte, len(replicas))
+			for i, node := range replicas {
+				replicaChecksums[i] = checksums[node]
+			}
+			source := majorityHolder(replicaChecksums, name)
+			if len(replicas) < 2 || source < 0 {
+				continue
+			}
+			videoId, filename := keyspace.Split(name)
+			for i, node := range replicas {
+				if bytes.Equal(replicaChecksums[i][name], replicaChecksums[source][name]) {
+					continue
+				}
+				if _, err := transferFile(context.Background(), replicas[source], node, videoId, filename); err != nil {
+					errs = append(errs, fmt.Sprintf("copy %s to %s: %v", name, node.address, err))
+					continue
+				}
+				repaired += 1
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return repaired, fmt.Errorf("%d copies failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return repaired, nil
+}
+
+// repairRange brings the replicas of the ring range (start, end] in line.
+// Where replicas disagree on a file, the checksum held by most of them wins,
+// with ties going to the earlier replica.
+func repairRange(replicas []*Node, start uint64, end uint64) (int, error) {
+	digests := make([]*proto.RangeDigestResponse, len(replicas))
+	for i, node := range replicas {
+		resp, err := node.client.GetRangeDigest(context.Background(), &proto.RangeDigestRequest{Start: start, End: end, Depth: antiEntropyDepth})
+		if err != nil {
+			return 0, fmt.Errorf("digest from %s: %w", node.address, err)
+		}
+		digests[i] = resp
+	}
+	if !slices.ContainsFunc(digests, func(d *proto.RangeDigestResponse) bool {
+		return !bytes.Equal(d.Root, digests[0].Root)
+	}) {
+		return 0, nil
+	}
+
+	repaired := 0
+	leafCount := len(digests[0].Leaves)
+	for leaf := range leafCount {
+		if !slices.ContainsFunc(digests, func(d *proto.RangeDigestResponse) bool {
+			return !bytes.Equal(d.Leaves[leaf], digests[0].Leaves[leaf])
+		}) {
+			continue
+		}
+		leafStart, leafEnd := keyspace.LeafRange(leaf, start, end, leafCount)
+		checksums := make([]map[string][]byte, len(replicas))
+		var names []string
+		for i, node := range replicas {
+			resp, err := node.client.GetRangeDigest(context.Background(), &proto.RangeDigestRequest{Start: leafStart, End: leafEnd, IncludeFiles: true})
+			if err != nil {
+				return repaired, fmt.Errorf("digest from %s: %w", node.address, err)
+			}
+			checksums[i] = make(map[string][]byte)
+			for _, f := range resp.Files {
+				checksums[i][f.Name] = f.Checksum
+				names = append(names, f.Name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range slices.Compact(names) {
+			source := majorityHolder(checksums, name)
+			videoId, filename := keyspace.Split(name)
+			for i, node := range replicas {
+				if bytes.Equal(checksums[i][name], checksums[source][name]) {
+					continue
+				}
+				if _, err := transferFile(context.Background(), replicas[source], node, videoId, filename); err != nil {
+					return repaired, fmt.Errorf("copy %s to %s: %w", name, node.address, err)
+				}
+				repaired += 1
+			}
+		}
+	}
+	return repaired, nil
+}
+
+// majorityHolder returns the index of the first replica holding the version
+// of name that most replicas agree on.
+func majorityHolder(checksums []map[string][]byte, name string) int {
+	best, bestVotes := -1, 0
+	for i := range checksums {
+		sum, ok := checksums[i][name]
+		if !ok {
+			continue
+		}
+		votes := 0
+		for j := range checksums {
+			if other, ok := checksums[j][name]; ok && bytes.Equal(sum, other) {
+				votes += 1
+			}
+		}
+		if votes > bestVotes {
+			best, bestVotes = i, votes
+		}
+	}
+	return best
+}
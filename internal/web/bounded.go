@@ -0,0 +1,275 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLoadFactor is how far above its fair share a node may be loaded
+// under bounded-load placement when no factor is given.
+const defaultLoadFactor = 1.25
+
+// boundedPlacement is consistent hashing with bounded loads. Each ring range
+// (between two adjacent tokens) goes to the first nodes clockwise from it that
+// have room, where a node has room while its load, by file count or bytes, is
+// at most factor times its share by weight of the total. Ranges that would
+// overfill a node spill to the next node on the ring.
+//
+// Loads are not looked up live: they are measured per ring range by
+// rebalanceLoads and saved with the ring, so every lookup places a key the
+// same way until the next measurement.
+type boundedPlacement struct {
+	factor  float64
+	byBytes bool
+}
+
+func (p boundedPlacement) Name() string {
+	metric := "files"
+	if p.byBytes {
+		metric = "bytes"
+	}
+	return fmt.Sprintf("bounded:%s:%s", strconv.FormatFloat(p.factor, 'g', -1, 64), metric)
+}
+
+func (p boundedPlacement) replicas(r *hashRing, hash uint64, count int) []*Node {
+	if len(r.tokens) == 0 {
+		return nil
+	}
+	idx := sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i] >= hash
+	}) % len(r.tokens)
+	return slices.Clone(r.boundedAssignment(p, count)[idx])
+}
+
+func (boundedPlacement) rangeBased() bool { return true }
+
+// parseBounded parses the options of "bounded[:factor[:files|bytes]]".
+func parseBounded(options string) (PlacementStrategy, error) {
+	p := boundedPlacement{factor: defaultLoadFactor}
+	factor, metric, _ := strings.Cut(options, ":")
+	if factor != "" {
+		f, err := strconv.ParseFloat(factor, 64)
+		if err != nil || f < 1 || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("load factor %q must be a number of at least 1", factor)
+		}
+		p.factor = f
+	}
+	switch metric {
+	case "", "files":
+	case "bytes":
+		p.byBytes = true
+	default:
+		return nil, fmt.Errorf("load metric %q must be files or bytes", metric)
+	}
+	return p, nil
+}
+
+// rangeLoad is the number of files and bytes stored in one ring range.
+// Ranges split by a membership change share their load in proportion to
+// their width, so loads can be fractional.
+type rangeLoad struct {
+	Files float64 `json:"files"`
+	Bytes float64 `json:"bytes"`
+}
+
+// boundedAssignment returns the replicas of every ring range, indexed like
+// r.tokens, computing them on first use after a change to r.
+func (r *hashRing) boundedAssignment(p boundedPlacement, count int) [][]*Node {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if assignment, ok := r.cache[count]; ok {
+		return assignment
+	}
+	nodes := r.nodes()
+	count = min(count, len(nodes))
+	assignment := make([][]*Node, len(r.tokens))
+	loads := make([]float64, len(r.tokens))
+	total, totalWeight := 0.0, 0.0
+	for i, token := range r.tokens {
+		if p.byBytes {
+			loads[i] = r.loads[token].Bytes
+		} else {
+			loads[i] = r.loads[token].Files
+		}
+		total += loads[i] * float64(count)
+	}
+	for _, node := range nodes {
+		totalWeight += node.weight
+	}
+	used := make(map[*Node]float64, len(nodes))
+	for i, token := range r.tokens {
+		// Ranges are handed out in token order, each to the first nodes
+		// clockwise that it still fits on. With nothing measured yet, every
+		// range fits and this is plain consistent hashing.
+		replicas := r.pick(count, func(n int) []*Node {
+			prefs := make([]*Node, 0, n)
+			for j := 0; j < len(r.tokens) && len(prefs) < n; j++ {
+				node := r.owners[r.tokens[(i+j)%len(r.tokens)]]
+				limit := p.factor * total * node.weight / totalWeight
+				if !slices.Contains(prefs, node) && used[node]+loads[i] <= limit {
+					prefs = append(prefs, node)
+				}
+			}
+			// A range too big to fit anywhere goes to its plain
+			// successors.
+			for _, node := range r.successors(token, len(nodes)) {
+				if len(prefs) == n {
+					break
+				}
+				if !slices.Contains(prefs, node) {
+					prefs = append(prefs, node)
+				}
+			}
+			return prefs
+		})
+		for _, node := range replicas {
+			used[node] += loads[i]
+		}
+		assignment[i] = replicas
+	}
+	if r.cache == nil {
+		r.cache = make(map[int][][]*Node)
+	}
+	r.cache[count] = assignment
+	return assignment
+}
+
+// rebinLoads returns the loads of the ranges ending at tokens, given the
+// loads of the ranges ending at old. Each old range's load is shared among
+// the new ranges overlapping it in proportion to the overlap.
+func rebinLoads(old []uint64, loads map[uint64]rangeLoad, tokens []uint64) map[uint64]rangeLoad {
+	rebinned := make(map[uint64]rangeLoad, len(tokens))
+	if len(old) == 0 || len(tokens) == 0 {
+		return rebinned
+	}
+	for i, end := range tokens {
+		start := tokens[(i+len(tokens)-1)%len(tokens)]
+		var sum rangeLoad
+		for j, oldEnd := range old {
+			oldStart := old[(j+len(old)-1)%len(old)]
+			share := arcOverlap(start, end, oldStart, oldEnd) / arcWidth(oldStart, oldEnd)
+			if share > 0 {
+				sum.Files += loads[oldEnd].Files * share
+				sum.Bytes += loads[oldEnd].Bytes * share
+			}
+		}
+		rebinned[end] = sum
+	}
+	return rebinned
+}
+
+// arcWidth is the width of the ring range (start, end]; equal bounds are the
+// whole ring.
+func arcWidth(start uint64, end uint64) float64 {
+	if start == end {
+		return math.Exp2(64)
+	}
+	return float64(end - start)
+}
+
+// arcOverlap is the width of the intersection of the ring ranges (aStart,
+// aEnd] and (bStart, bEnd].
+func arcOverlap(aStart uint64, aEnd uint64, bStart uint64, bEnd uint64) float64 {
+	if aStart == aEnd {
+		return arcWidth(bStart, bEnd)
+	}
+	if bStart == bEnd {
+		return arcWidth(aStart, aEnd)
+	}
+	// Measure from aStart, so a is (0, aWidth] and b is (offset, end], which
+	// may wrap past the end of the ring back to the start of a. The widths
+	// are summed as integers, since floats lose the small ones.
+	aWidth := aEnd - aStart
+	offset := bStart - aStart
+	end := offset + (bEnd - bStart)
+	wraps := end < offset
+	var overlap uint64
+	if offset < aWidth {
+		if wraps {
+			overlap += aWidth - offset
+		} else {
+			overlap += min(aWidth, end) - offset
+		}
+	}
+	if wraps {
+		overlap += min(aWidth, end)
+	}
+	return float64(overlap)
+}
+
+// runLoadBalancer measures range loads under bounded-load placement now and
+// every interval after.
+func (n *NetworkVideoContentService) runLoadBalancer(interval time.Duration) {
+	for {
+		if err := n.rebalanceLoads(); err != nil {
+			log.Printf("Load balancing: %v\n", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// rebalanceLoads measures the load of every ring range and saves it with the
+// ring. If the new loads change where any range is placed, the ring goes
+// through a transition like a membership change and a migration job moves
+// files to their new replicas. It does nothing unless the placement is
+// bounded-load or while a migration runs.
+func (n *NetworkVideoContentService) rebalanceLoads() error {
+	n.mu.RLock()
+	p, ok := basePlacement(n.ring.placement).(boundedPlacement)
+	r := n.ring.clone()
+	version := n.ringVersion
+	busy := n.transitions > 0
+	n.mu.RUnlock()
+	if !ok || busy || len(r.tokens) == 0 || !n.migrationMu.TryLock() {
+		return nil
+	}
+	loads := make(map[uint64]rangeLoad, len(r.tokens))
+	err := walkAll(context.Background(), slices.DeleteFunc(r.nodes(), isDown), nil)(func(key string, file *heldFile) error {
+		hash := r.hashKey(key)
+		token := r.tokens[sort.Search(len(r.tokens), func(i int) bool {
+			return r.tokens[i] >= hash
+		})%len(r.tokens)]
+		load := loads[token]
+		load.Files += 1
+		load.Bytes += float64(file.size)
+		loads[token] = load
+		return nil
+	})
+	n.migrationMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ringVersion != version || n.transitions > 0 {
+		// The ring changed while listing; the next pass measures again.
+		return nil
+	}
+	measured := n.ring.clone()
+	measured.loads = loads
+	changed := false
+	for _, token := range n.ring.tokens {
+		if !slices.Equal(p.replicas(n.ring, token, n.replicationFactor), p.replicas(measured, token, n.replicationFactor)) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		n.ring.setLoads(loads)
+		n.saveRingLocked()
+		return nil
+	}
+	n.beginTransitionLocked()
+	n.ring.setLoads(loads)
+	job := n.startJob(opPlacement, nil)
+	log.Printf("Range loads changed placement; migration %s moves files to their new replicas\n", job.ID)
+	return nil
+}
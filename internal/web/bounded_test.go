@@ -0,0 +1,148 @@
+package web
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// ringSize is the number of hashes on the ring.
+var ringSize = math.Exp2(64)
+
+func TestArcOverlap(t *testing.T) {
+	tests := []struct {
+		name                       string
+		aStart, aEnd, bStart, bEnd uint64
+		want                       float64
+	}{
+		{"same", 10, 20, 10, 20, 10},
+		{"inside", 10, 20, 12, 15, 3},
+		{"containing", 12, 15, 10, 20, 3},
+		{"overlapping end", 10, 20, 15, 30, 5},
+		{"overlapping start", 15, 30, 10, 20, 5},
+		{"touching", 10, 20, 20, 30, 0},
+		{"disjoint", 10, 20, 30, 40, 0},
+		{"a wraps", math.MaxUint64 - 9, 10, 0, 5, 5},
+		{"b wraps", 0, 5, math.MaxUint64 - 9, 10, 5},
+		{"both wrap", math.MaxUint64 - 9, 10, math.MaxUint64 - 4, 20, 15},
+		{"b wraps past a's start", 10, 20, 15, 12, 7},
+		{"a whole ring", 7, 7, 10, 20, 10},
+		{"b whole ring", 10, 20, 7, 7, 10},
+		{"both whole ring", 7, 7, 100, 100, ringSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := arcOverlap(tt.aStart, tt.aEnd, tt.bStart, tt.bEnd)
+			if got != tt.want {
+				t.Errorf("arcOverlap(%d, %d, %d, %d) = %g, want %g", tt.aStart, tt.aEnd, tt.bStart, tt.bEnd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebinLoads(t *testing.T) {
+	quarter := uint64(1) << 62
+	tests := []struct {
+		name   string
+		old    []uint64
+		loads  map[uint64]rangeLoad
+		tokens []uint64
+		want   map[uint64]rangeLoad
+	}{
+		{
+			name:   "unchanged",
+			old:    []uint64{quarter, 3 * quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 4, Bytes: 40}, 3 * quarter: {Files: 8, Bytes: 80}},
+			tokens: []uint64{quarter, 3 * quarter},
+			want:   map[uint64]rangeLoad{quarter: {Files: 4, Bytes: 40}, 3 * quarter: {Files: 8, Bytes: 80}},
+		},
+		{
+			// A token at 2*quarter splits the range (quarter, 3*quarter]
+			// in half.
+			name:   "split",
+			old:    []uint64{quarter, 3 * quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 4, Bytes: 40}, 3 * quarter: {Files: 8, Bytes: 80}},
+			tokens: []uint64{quarter, 2 * quarter, 3 * quarter},
+			want:   map[uint64]rangeLoad{quarter: {Files: 4, Bytes: 40}, 2 * quarter: {Files: 4, Bytes: 40}, 3 * quarter: {Files: 4, Bytes: 40}},
+		},
+		{
+			// The range (3*quarter, quarter] wraps past zero.
+			name:   "split across zero",
+			old:    []uint64{quarter, 3 * quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 4, Bytes: 40}, 3 * quarter: {Files: 8, Bytes: 80}},
+			tokens: []uint64{0, quarter, 3 * quarter},
+			want:   map[uint64]rangeLoad{0: {Files: 2, Bytes: 20}, quarter: {Files: 2, Bytes: 20}, 3 * quarter: {Files: 8, Bytes: 80}},
+		},
+		{
+			name:   "merged",
+			old:    []uint64{quarter, 2 * quarter, 3 * quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 1}, 2 * quarter: {Files: 2}, 3 * quarter: {Files: 3}},
+			tokens: []uint64{quarter, 3 * quarter},
+			want:   map[uint64]rangeLoad{quarter: {Files: 1}, 3 * quarter: {Files: 5}},
+		},
+		{
+			name:   "one token",
+			old:    []uint64{quarter, 3 * quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 4}, 3 * quarter: {Files: 8}},
+			tokens: []uint64{2 * quarter},
+			want:   map[uint64]rangeLoad{2 * quarter: {Files: 12}},
+		},
+		{
+			name:   "from one token",
+			old:    []uint64{2 * quarter},
+			loads:  map[uint64]rangeLoad{2 * quarter: {Files: 12}},
+			tokens: []uint64{quarter, 3 * quarter},
+			want:   map[uint64]rangeLoad{quarter: {Files: 6}, 3 * quarter: {Files: 6}},
+		},
+		{
+			name:   "from empty ring",
+			old:    nil,
+			loads:  map[uint64]rangeLoad{},
+			tokens: []uint64{quarter},
+			want:   map[uint64]rangeLoad{},
+		},
+		{
+			name:   "to empty ring",
+			old:    []uint64{quarter},
+			loads:  map[uint64]rangeLoad{quarter: {Files: 1}},
+			tokens: nil,
+			want:   map[uint64]rangeLoad{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rebinLoads(tt.old, tt.loads, tt.tokens)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rebinLoads = %v, want %v", got, tt.want)
+			}
+			for token, want := range tt.want {
+				load := got[token]
+				if math.Abs(load.Files-want.Files) > 1e-9 || math.Abs(load.Bytes-want.Bytes) > 1e-9 {
+					t.Errorf("load of range ending at %d = %+v, want %+v", token, load, want)
+				}
+			}
+		})
+	}
+}
+
+// TestRebinLoadsConserves checks that rebinning neither creates nor loses
+// load.
+func TestRebinLoadsConserves(t *testing.T) {
+	old := nodeTokens("a", 16)
+	tokens := append(nodeTokens("b", 16), old[:8]...)
+	slices.Sort(tokens)
+	slices.Sort(old)
+	loads := make(map[uint64]rangeLoad, len(old))
+	total := 0.0
+	for i, token := range old {
+		loads[token] = rangeLoad{Files: float64(i + 1)}
+		total += float64(i + 1)
+	}
+	sum := 0.0
+	for _, load := range rebinLoads(old, loads, tokens) {
+		sum += load.Files
+	}
+	if math.Abs(sum-total) > 1e-6 {
+		t.Errorf("rebinned loads total %g, want %g", sum, total)
+	}
+}
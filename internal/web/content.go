@@ -0,0 +1,93 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"tritontube/internal/filestream"
+)
+
+// contentReader presents a stored file as an io.ReadSeeker for
+// http.ServeContent, fetching only the ranges that are read, a chunk at a
+// time. Ranges cannot be checked against the file's checksum, so when whole
+// is set the file is instead fetched whole through Read, which checks it.
+type contentReader struct {
+	service  VideoContentService
+	videoId  string
+	filename string
+	size     int64
+	whole    bool
+	offset   int64
+	buf      []byte
+}
+
+func (r *contentReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if len(r.buf) == 0 && r.whole && r.offset == 0 {
+		data, err := r.service.Read(r.videoId, r.filename)
+		if err != nil {
+			return 0, err
+		}
+		if int64(len(data)) != r.size {
+			return 0, errors.New("file changed while being served")
+		}
+		r.buf = data
+	}
+	if len(r.buf) == 0 {
+		data, _, err := r.service.ReadRange(r.videoId, r.filename, r.offset, min(filestream.ChunkSize, r.size-r.offset))
+		if err != nil {
+			return 0, err
+		}
+		if len(data) == 0 {
+			// The file shrank since it was described.
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+	return n, nil
+}
+
+func (r *contentReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before start of file")
+	}
+	if offset != r.offset {
+		r.buf = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// wholeFileRequest reports whether a request with the given Range header
+// asks for every byte of a file of the given size. Only a single range
+// starting at 0 can cover the file; anything else is served in ranges.
+func wholeFileRequest(rangeHeader string, size int64) bool {
+	if rangeHeader == "" {
+		return true
+	}
+	spec, ok := strings.CutPrefix(rangeHeader, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return false
+	}
+	start, end, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok || start != "0" {
+		return false
+	}
+	if end == "" {
+		return true
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	return err == nil && last >= size-1
+}
@@ -0,0 +1,220 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tritontube/internal/filestream"
+)
+
+func TestWholeFileRequest(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{"bytes=0-", true},
+		{"bytes=0-99", true},
+		{"bytes=0-1000", true},
+		{"bytes= 0-99", true},
+		{"bytes=0-98", false},
+		{"bytes=1-", false},
+		{"bytes=-100", false},
+		{"bytes=50-99", false},
+		{"bytes=0-49,50-99", false},
+		{"bytes=0-x", false},
+		{"items=0-", false},
+		{"bytes=0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := wholeFileRequest(tt.header, 100); got != tt.want {
+				t.Errorf("wholeFileRequest(%q, 100) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// countingContent counts the whole-file and ranged reads made of a
+// VideoContentService.
+type countingContent struct {
+	VideoContentService
+	reads      int
+	rangeReads int
+}
+
+func (c *countingContent) Read(videoId string, filename string) ([]byte, error) {
+	c.reads += 1
+	return c.VideoContentService.Read(videoId, filename)
+}
+
+func (c *countingContent) ReadRange(videoId string, filename string, offset int64, length int64) ([]byte, *ContentInfo, error) {
+	c.rangeReads += 1
+	return c.VideoContentService.ReadRange(videoId, filename, offset, length)
+}
+
+// testContent returns a file spanning a few chunks, stored in a new
+// filesystem content service as video/segment.m4s.
+func testContent(t *testing.T) ([]byte, *FSVideoContentService) {
+	t.Helper()
+	data := make([]byte, 2*filestream.ChunkSize+1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	fs := &FSVideoContentService{}
+	if err := fs.Initialize(filepath.Join(t.TempDir(), "content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write("video", "segment.m4s", data); err != nil {
+		t.Fatal(err)
+	}
+	return data, fs
+}
+
+func TestHandleVideoContentRange(t *testing.T) {
+	data, fs := testContent(t)
+	size := len(data)
+	chunk := filestream.ChunkSize
+	tests := []struct {
+		name   string
+		header string
+		code   int
+		// start and end bound the bytes served when code is 206.
+		start, end int
+		// whole is set if the file should be read whole, through the
+		// checksum-verified path.
+		whole bool
+	}{
+		{"no range", "", http.StatusOK, 0, size, true},
+		{"open range from zero", "bytes=0-", http.StatusPartialContent, 0, size, true},
+		{"whole file", fmt.Sprintf("bytes=0-%d", size-1), http.StatusPartialContent, 0, size, true},
+		{"past the end", fmt.Sprintf("bytes=0-%d", size+100), http.StatusPartialContent, 0, size, true},
+		{"prefix", "bytes=0-99", http.StatusPartialContent, 0, 100, false},
+		{"middle", "bytes=100-199", http.StatusPartialContent, 100, 200, false},
+		{"across chunks", fmt.Sprintf("bytes=%d-%d", chunk-10, 2*chunk+10), http.StatusPartialContent, chunk - 10, 2*chunk + 11, false},
+		{"open range", fmt.Sprintf("bytes=%d-", chunk), http.StatusPartialContent, chunk, size, false},
+		{"suffix", "bytes=-100", http.StatusPartialContent, size - 100, size, false},
+		{"unsatisfiable", fmt.Sprintf("bytes=%d-", size), http.StatusRequestedRangeNotSatisfiable, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := &countingContent{VideoContentService: fs}
+			s := NewServer(fakeMetadata{}, content)
+			r := httptest.NewRequest(http.MethodGet, "/content/video/segment.m4s", nil)
+			if tt.header != "" {
+				r.Header.Set("Range", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.handleVideoContent(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d", w.Code, tt.code)
+			}
+			if tt.code == http.StatusRequestedRangeNotSatisfiable {
+				if content.reads+content.rangeReads > 0 {
+					t.Errorf("read the file %d times for an unsatisfiable range", content.reads+content.rangeReads)
+				}
+				return
+			}
+			if !bytes.Equal(w.Body.Bytes(), data[tt.start:tt.end]) {
+				t.Errorf("served %d bytes, want bytes [%d, %d)", w.Body.Len(), tt.start, tt.end)
+			}
+			if tt.code == http.StatusPartialContent {
+				want := fmt.Sprintf("bytes %d-%d/%d", tt.start, tt.end-1, size)
+				if got := w.Header().Get("Content-Range"); got != want {
+					t.Errorf("Content-Range %q, want %q", got, want)
+				}
+			}
+			if tt.whole && (content.reads != 1 || content.rangeReads != 0) {
+				t.Errorf("%d whole and %d ranged reads, want the file read whole once", content.reads, content.rangeReads)
+			}
+			if !tt.whole && (content.reads != 0 || content.rangeReads == 0) {
+				t.Errorf("%d whole and %d ranged reads, want only ranged reads", content.reads, content.rangeReads)
+			}
+		})
+	}
+
+	t.Run("several ranges", func(t *testing.T) {
+		content := &countingContent{VideoContentService: fs}
+		s := NewServer(fakeMetadata{}, content)
+		r := httptest.NewRequest(http.MethodGet, "/content/video/segment.m4s", nil)
+		r.Header.Set("Range", "bytes=0-9,100-109")
+		w := httptest.NewRecorder()
+		s.handleVideoContent(w, r)
+		if w.Code != http.StatusPartialContent || !strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges") {
+			t.Fatalf("status %d with type %q, want a multipart partial response", w.Code, w.Header().Get("Content-Type"))
+		}
+		if content.reads != 0 {
+			t.Errorf("read the file whole for two small ranges")
+		}
+	})
+}
+
+func TestContentReader(t *testing.T) {
+	data, fs := testContent(t)
+	size := int64(len(data))
+	tests := []struct {
+		name   string
+		offset int64
+		whence int
+		// want is the offset the seek ends at.
+		want int64
+	}{
+		{"start", 0, io.SeekStart, 0},
+		{"into the second chunk", filestream.ChunkSize + 5, io.SeekStart, filestream.ChunkSize + 5},
+		{"from the end", -10, io.SeekEnd, size - 10},
+		{"end", 0, io.SeekEnd, size},
+	}
+	for _, tt := range tests {
+		for _, whole := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s whole %v", tt.name, whole), func(t *testing.T) {
+				r := &contentReader{service: fs, videoId: "video", filename: "segment.m4s", size: size, whole: whole}
+				// Read a little first, so the seek drops buffered data.
+				if _, err := io.ReadFull(r, make([]byte, 10)); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := r.Seek(0, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+				offset, err := r.Seek(tt.offset, tt.whence)
+				if err != nil || offset != tt.want {
+					t.Fatalf("Seek(%d, %d) = %d, %v; want %d", tt.offset, tt.whence, offset, err, tt.want)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, data[tt.want:]) {
+					t.Errorf("read %d bytes after seeking to %d, want %d", len(got), tt.want, size-tt.want)
+				}
+			})
+		}
+	}
+
+	t.Run("before start", func(t *testing.T) {
+		r := &contentReader{service: fs, videoId: "video", filename: "segment.m4s", size: size}
+		if _, err := r.Seek(-1, io.SeekStart); err == nil {
+			t.Error("seek before the start succeeded")
+		}
+	})
+	t.Run("file shrank", func(t *testing.T) {
+		r := &contentReader{service: fs, videoId: "video", filename: "segment.m4s", size: size + 10}
+		if _, err := r.Seek(size, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Read(make([]byte, 10)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("read past the end of a shrunk file: %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+	t.Run("file changed", func(t *testing.T) {
+		r := &contentReader{service: fs, videoId: "video", filename: "segment.m4s", size: size + 10, whole: true}
+		if _, err := r.Read(make([]byte, 10)); err == nil {
+			t.Error("read of a file whose size changed succeeded")
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DrainNode takes a node off the ring so it receives no new writes, while it
+// keeps serving reads, and copies its files to their new owners in the
+// background. A drained node is removed with RemoveNode once it is empty.
+func (n *NetworkVideoContentService) DrainNode(ctx context.Context, req *proto.DrainNodeRequest) (*proto.DrainNodeResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.draining[req.NodeAddress]; ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is already draining", req.NodeAddress)
+	}
+	node := n.ring.lookup(req.NodeAddress)
+	if node == nil {
+		return nil, status.Errorf(codes.NotFound, "node %s is not in the cluster", req.NodeAddress)
+	}
+	if err := checkNotLast(n.ring, node); err != nil {
+		return nil, err
+	}
+	n.beginTransitionLocked()
+	n.ring.remove(node)
+	n.draining[node.address] = node
+
+	job := n.startJob(opDrain, node)
+	return &proto.DrainNodeResponse{JobId: job.ID}, nil
+}
+
+// removeDrainedLocked removes a draining node from the cluster if it no
+// longer holds any files. A node that is down cannot be asked, so it is
+// removed once its drain job has finished, as nothing more can be copied off
+// it. The caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) removeDrainedLocked(ctx context.Context, node *Node) error {
+	if health, _ := node.healthState(); health == healthDown {
+		if !n.drainFinished(node) {
+			return status.Errorf(codes.FailedPrecondition, "node %s is down and still draining", node.address)
+		}
+		n.forgetDrainedLocked(node)
+		return nil
+	}
+	resp, err := node.client.ListFiles(ctx, &proto.ListFilesRequest{PageSize: 1})
+	if err != nil {
+		return err
+	}
+	if len(resp.Filenames) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "node %s still holds files, such as %s", node.address, resp.Filenames[0])
+	}
+	n.forgetDrainedLocked(node)
+	return nil
+}
+
+// forgetDrainedLocked drops a draining node from the cluster. The caller must
+// hold n.mu for writing.
+func (n *NetworkVideoContentService) forgetDrainedLocked(node *Node) {
+	delete(n.draining, node.address)
+	n.ringChangedLocked()
+	node.conn.Close()
+}
+
+// drainFinished reports whether no drain job for node is pending or running.
+func (n *NetworkVideoContentService) drainFinished(node *Node) bool {
+	n.jobsMu.Lock()
+	defer n.jobsMu.Unlock()
+	for _, job := range n.jobs {
+		if job.Operation == opDrain && job.NodeAddress == node.address && !job.finished() {
+			return false
+		}
+	}
+	return true
+}
+
+// drainingNodesLocked returns the nodes that are draining. The caller must
+// hold n.mu.
+func (n *NetworkVideoContentService) drainingNodesLocked() []*Node {
+	nodes := make([]*Node, 0, len(n.draining))
+	for _, node := range n.draining {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
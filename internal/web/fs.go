@@ -3,14 +3,22 @@
 package web
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"tritontube/internal/fsutil"
+	"tritontube/internal/safepath"
 )
 
 // FSVideoContentService implements VideoContentService using the local filesystem.
 type FSVideoContentService struct {
 	storageDirectory string
+	// FileMode is the permission content files are created with;
+	// fsutil.DefaultFileMode when zero.
+	FileMode os.FileMode
 }
 
 // Uncomment the following line to ensure FSVideoContentService implements VideoContentService
@@ -27,6 +35,10 @@ func (fs *FSVideoContentService) Initialize(dir string) error {
 }
 
 func (fs *FSVideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	if err := safepath.Check(videoId, filename); err != nil {
+		log.Printf("FS Read: %v\n", err)
+		return nil, err
+	}
 	data, err := os.ReadFile(filepath.Join(fs.storageDirectory, videoId, filename))
 	if err != nil {
 		log.Printf("FS Read: %v\n", err)
@@ -35,14 +47,79 @@ func (fs *FSVideoContentService) Read(videoId string, filename string) ([]byte,
 	return data, nil
 }
 
+func (fs *FSVideoContentService) ReadRange(videoId string, filename string, offset int64, length int64) ([]byte, *ContentInfo, error) {
+	if err := safepath.Check(videoId, filename); err != nil {
+		log.Printf("FS Read: %v\n", err)
+		return nil, nil, err
+	}
+	if offset < 0 || length < 0 {
+		return nil, nil, errors.New("offset and length must not be negative")
+	}
+	f, err := os.Open(filepath.Join(fs.storageDirectory, videoId, filename))
+	if err != nil {
+		log.Printf("FS Read: %v\n", err)
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Printf("FS Read: %v\n", err)
+		return nil, nil, err
+	}
+	start := min(offset, info.Size())
+	end := info.Size()
+	if length > 0 && length < end-start {
+		end = start + length
+	}
+	data := make([]byte, end-start)
+	if _, err := f.ReadAt(data, start); err != nil && err != io.EOF {
+		log.Printf("FS Read: %v\n", err)
+		return nil, nil, err
+	}
+	return data, fileContentInfo(info), nil
+}
+
+func (fs *FSVideoContentService) Stat(videoId string, filename string) (*ContentInfo, error) {
+	if err := safepath.Check(videoId, filename); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filepath.Join(fs.storageDirectory, videoId, filename))
+	if err != nil {
+		return nil, err
+	}
+	return fileContentInfo(info), nil
+}
+
+// fileContentInfo describes a local file. Its ETag is derived from the
+// modification time and size, which every write changes.
+func fileContentInfo(info os.FileInfo) *ContentInfo {
+	return &ContentInfo{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		ETag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+}
+
 func (fs *FSVideoContentService) Write(videoId string, filename string, data []byte) error {
+	return fs.write(videoId, filename, data, false)
+}
+
+func (fs *FSVideoContentService) Create(videoId string, filename string, data []byte) error {
+	return fs.write(videoId, filename, data, true)
+}
+
+func (fs *FSVideoContentService) write(videoId string, filename string, data []byte, createOnly bool) error {
+	if err := safepath.Check(videoId, filename); err != nil {
+		log.Printf("FS Write: %v\n", err)
+		return err
+	}
 	dir := filepath.Join(fs.storageDirectory, videoId)
 	err := os.MkdirAll(dir, 0777)
 	if err != nil {
 		log.Printf("FS Write: %v\n", err)
 		return err
 	}
-	err = os.WriteFile(filepath.Join(dir, filename), data, 0777)
+	err = fsutil.WriteFile(filepath.Join(dir, filename), data, fs.FileMode, createOnly)
 	if err != nil {
 		log.Printf("FS Write: %v\n", err)
 		return err
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// traversalNames are video ID and filename pairs that would reach outside a
+// video's directory if joined onto a path.
+var traversalNames = []struct {
+	name     string
+	videoId  string
+	filename string
+}{
+	{"parent video ID", "..", "x.mp4"},
+	{"parent filename", "video", ".."},
+	{"escaping filename", "video", "../x.mp4"},
+	{"escaping video ID", "../video", "x.mp4"},
+	{"nested filename", "video", "a/x.mp4"},
+	{"absolute filename", "video", "/tmp/x.mp4"},
+	{"backslash", `..\video`, "x.mp4"},
+	{"dot video ID", ".", "x.mp4"},
+	{"empty video ID", "", "x.mp4"},
+	{"empty filename", "video", ""},
+}
+
+func TestFSTraversal(t *testing.T) {
+	base := t.TempDir()
+	fs := &FSVideoContentService{}
+	if err := fs.Initialize(filepath.Join(base, "content")); err != nil {
+		t.Fatal(err)
+	}
+	// A file next to the content directory, which no name may reach.
+	if err := os.WriteFile(filepath.Join(base, "x.mp4"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range traversalNames {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := fs.Write(tt.videoId, tt.filename, []byte("x")); err == nil {
+				t.Error("Write succeeded")
+			}
+			if err := fs.Create(tt.videoId, tt.filename, []byte("x")); err == nil {
+				t.Error("Create succeeded")
+			}
+			if _, err := fs.Read(tt.videoId, tt.filename); err == nil {
+				t.Error("Read succeeded")
+			}
+			if _, _, err := fs.ReadRange(tt.videoId, tt.filename, 0, 0); err == nil {
+				t.Error("ReadRange succeeded")
+			}
+			if _, err := fs.Stat(tt.videoId, tt.filename); err == nil {
+				t.Error("Stat succeeded")
+			}
+		})
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "content" && entry.Name() != "x.mp4" {
+			t.Errorf("unexpected %s written", entry.Name())
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(base, "x.mp4")); err != nil || string(data) != "secret" {
+		t.Errorf("file outside the content directory is %q, %v", data, err)
+	}
+	entries, err = os.ReadDir(filepath.Join(base, "content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("unexpected %s written", entry.Name())
+	}
+}
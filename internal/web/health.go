@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Node health states reported by ListNodes.
+const (
+	healthUp      = "up"
+	healthSuspect = "suspect"
+	healthDown    = "down"
+)
+
+// downAfterFailures is the number of consecutive failed checks after which a
+// suspect node is considered down.
+const downAfterFailures = 3
+
+// storageServiceName is the service name storage nodes report health for.
+const storageServiceName = "tritontube.StorageService"
+
+// recordCheck updates the node's health with the outcome of one check and
+// returns its new state.
+func (node *Node) recordCheck(ok bool) string {
+	node.healthMu.Lock()
+	defer node.healthMu.Unlock()
+	if ok {
+		if node.health != healthUp {
+			log.Printf("Node %s is up\n", node.address)
+		}
+		node.health = healthUp
+		node.failures = 0
+		return node.health
+	}
+	node.failures += 1
+	switch {
+	case node.failures >= downAfterFailures && node.health != healthDown:
+		log.Printf("Node %s is down\n", node.address)
+		node.health = healthDown
+		node.downSince = time.Now()
+	case node.health == healthUp:
+		log.Printf("Node %s is suspect\n", node.address)
+		node.health = healthSuspect
+	}
+	return node.health
+}
+
+// healthState returns the node's current health and, if it is down, since when.
+func (node *Node) healthState() (string, time.Time) {
+	node.healthMu.Lock()
+	defer node.healthMu.Unlock()
+	return node.health, node.downSince
+}
+
+// checkNode asks a storage node for its health using the standard gRPC
+// health protocol. Nodes that predate the health service still count as
+// alive as long as they answer.
+func checkNode(node *Node, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(node.conn).Check(ctx, &healthpb.HealthCheckRequest{Service: storageServiceName})
+	if status.Code(err) == codes.Unimplemented {
+		return true
+	}
+	return err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+}
+
+// monitorHealth checks every node in the cluster each interval. If ejectAfter is
+// positive, nodes that stay down that long are taken off the ring and their
+// files are re-replicated from the surviving replicas.
+func (n *NetworkVideoContentService) monitorHealth(interval time.Duration, ejectAfter time.Duration) {
+	for range time.Tick(interval) {
+		n.mu.RLock()
+		nodes := append(n.ring.nodes(), n.drainingNodesLocked()...)
+		n.mu.RUnlock()
+		for _, node := range nodes {
+			go func() {
+				state := node.recordCheck(checkNode(node, interval))
+				if state != healthDown || ejectAfter <= 0 {
+					return
+				}
+				if _, since := node.healthState(); time.Since(since) >= ejectAfter {
+					n.ejectNode(node)
+				}
+			}()
+		}
+	}
+}
+
+// ejectNode removes a dead node from the ring. Unlike RemoveNode, the node is
+// not used as a migration source. A dead draining node is already off the
+// ring, so it is dropped once its drain job has finished.
+func (n *NetworkVideoContentService) ejectNode(node *Node) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.draining[node.address] == node {
+		if n.drainFinished(node) {
+			log.Printf("Removing draining node %s after it stayed down\n", node.address)
+			n.forgetDrainedLocked(node)
+		}
+		return
+	}
+	if n.ring.lookup(node.address) != node {
+		return
+	}
+	if err := checkNotLast(n.ring, node); err != nil {
+		// With no other node to re-replicate to, the node stays on the
+		// ring in case it comes back.
+		return
+	}
+	log.Printf("Ejecting node %s after it stayed down\n", node.address)
+	n.beginTransitionLocked()
+	n.ring.remove(node)
+	n.startJob(opEject, node)
+}
@@ -13,7 +13,24 @@ type VideoMetadataService interface {
 	Create(videoId string, uploadedAt time.Time) error
 }
 
+// ContentInfo describes a stored file without its data.
+type ContentInfo struct {
+	Size    int64
+	ModTime time.Time
+	// ETag is a quoted HTTP entity tag that changes whenever the file does.
+	ETag string
+}
+
 type VideoContentService interface {
 	Read(videoId string, filename string) ([]byte, error)
+	// ReadRange reads length bytes of a file starting at offset, or up to
+	// the end of the file if length is 0, and describes the whole file.
+	ReadRange(videoId string, filename string, offset int64, length int64) ([]byte, *ContentInfo, error)
+	// Stat describes a file. Both Stat and ReadRange fail with an error
+	// matching os.ErrNotExist if there is no such file.
+	Stat(videoId string, filename string) (*ContentInfo, error)
 	Write(videoId string, filename string, data []byte) error
+	// Create is like Write but fails with an error matching os.ErrExist
+	// instead of replacing a file that already exists.
+	Create(videoId string, filename string, data []byte) error
 }
@@ -0,0 +1,242 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"tritontube/internal/fsutil"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Journal record kinds. A move is journaled as begin before anything is
+// copied, copied once every destination has the file, and done once the
+// extra copies are deleted. A move that fails is journaled as aborted; the
+// next migration plans the file afresh.
+const (
+	journalBegin   = "begin"
+	journalCopied  = "copied"
+	journalDone    = "done"
+	journalAborted = "aborted"
+)
+
+// journalEntry is one line of the migration journal.
+type journalEntry struct {
+	ID         int64    `json:"id"`
+	Op         string   `json:"op"`
+	Key        string   `json:"key,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	CopyTo     []string `json:"copy_to,omitempty"`
+	DeleteFrom []string `json:"delete_from,omitempty"`
+}
+
+// migrationJournal is a write-ahead log of file moves kept in the state
+// directory, so moves interrupted by a crash can be finished on startup. A nil
+// journal records nothing.
+type migrationJournal struct {
+	mu   sync.Mutex
+	file *os.File
+	next int64
+	open map[int64]bool
+}
+
+// openJournal opens the journal in stateDir and returns the moves it holds
+// that were begun but never finished.
+func openJournal(stateDir string) (*migrationJournal, []*journalEntry, error) {
+	if stateDir == "" {
+		return nil, nil, nil
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(stateDir, "journal.log"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Sync the directory so a newly created journal is not lost in a crash.
+	if err := fsutil.SyncDir(stateDir); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	j := &migrationJournal{file: file, open: make(map[int64]bool)}
+	entries := make(map[int64]*journalEntry)
+	reader := bufio.NewReader(file)
+	// end is the offset just past the last complete line.
+	var end int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A crash mid-append leaves a torn final line. It is cut off
+			// so the next record does not end up on the same line.
+			if len(line) > 0 {
+				if err := file.Truncate(end); err != nil {
+					file.Close()
+					return nil, nil, err
+				}
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		end += int64(len(line))
+		entry := &journalEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			// A line that cannot be parsed is ignored.
+			continue
+		}
+		j.next = max(j.next, entry.ID+1)
+		switch entry.Op {
+		case journalBegin:
+			entries[entry.ID] = entry
+		case journalCopied:
+			if begun, ok := entries[entry.ID]; ok {
+				begun.Op = journalCopied
+			}
+		case journalDone, journalAborted:
+			delete(entries, entry.ID)
+		}
+	}
+	pending := make([]*journalEntry, 0, len(entries))
+	for _, entry := range entries {
+		j.open[entry.ID] = true
+		pending = append(pending, entry)
+	}
+	slices.SortFunc(pending, func(a, b *journalEntry) int {
+		return int(a.ID - b.ID)
+	})
+	return j, pending, nil
+}
+
+// append writes entry to the journal and syncs it to disk.
+func (j *migrationJournal) append(entry *journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// begin records a move before any of it happens and returns its ID.
+func (j *migrationJournal) begin(key string, source string, copyTo []string, deleteFrom []string) (int64, error) {
+	if j == nil {
+		return 0, nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	id := j.next
+	j.next += 1
+	err := j.append(&journalEntry{ID: id, Op: journalBegin, Key: key, Source: source, CopyTo: copyTo, DeleteFrom: deleteFrom})
+	if err != nil {
+		return 0, err
+	}
+	j.open[id] = true
+	return id, nil
+}
+
+// mark records that move id reached op. Once no move is open the journal is
+// truncated so it does not grow without bound.
+func (j *migrationJournal) mark(id int64, op string) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(&journalEntry{ID: id, Op: op}); err != nil {
+		return err
+	}
+	if op != journalDone && op != journalAborted {
+		return nil
+	}
+	delete(j.open, id)
+	if len(j.open) > 0 {
+		return nil
+	}
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// recoverJournal finishes the moves left open by a crash. The ring may have
+// changed since a move was journaled, so each one is planned again against
+// the current ring: the file is copied to the replicas missing it and deleted
+// only from holders outside its replica set. Moves that cannot be finished
+// now stay in the journal for the next startup. The caller must hold n.mu for
+// writing.
+func (n *NetworkVideoContentService) recoverJournal(pending []*journalEntry) {
+	for _, entry := range pending {
+		if err := n.recoverMove(entry); err != nil {
+			log.Printf("Journal: move %d of %s not recovered: %v\n", entry.ID, entry.Key, err)
+			continue
+		}
+		log.Printf("Journal: recovered move %d of %s\n", entry.ID, entry.Key)
+	}
+}
+
+func (n *NetworkVideoContentService) recoverMove(entry *journalEntry) error {
+	videoId, filename := keyspace.Split(entry.Key)
+	// Any node the move touched, or that should hold the file now, may hold
+	// it; each is asked.
+	addrs := append([]string{entry.Source}, entry.CopyTo...)
+	addrs = append(addrs, entry.DeleteFrom...)
+	addrs = append(addrs, nodeAddresses(n.ring.keyReplicas(entry.Key, n.replicationFactor))...)
+	slices.Sort(addrs)
+	file := &heldFile{}
+	for _, addr := range slices.Compact(addrs) {
+		node, err := n.nodeByAddressLocked(addr)
+		if err != nil {
+			return err
+		}
+		resp, err := node.client.StatFile(context.Background(), &proto.StatFileRequest{VideoId: videoId, Filename: filename})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("stat on %s: %w", addr, err)
+		}
+		file.holders = append(file.holders, node)
+		file.size = resp.Size
+	}
+	if len(file.holders) == 0 {
+		// The file was deleted since; there is nothing left to move.
+		return n.journal.mark(entry.ID, journalDone)
+	}
+	m, ok := n.planMove(entry.Key, file, n.ring)
+	if !ok {
+		return n.journal.mark(entry.ID, journalDone)
+	}
+	_, _, err := n.applyMove(m, entry.ID)
+	return err
+}
+
+// nodeByAddressLocked returns the known node with addr, or connects to it if
+// it has already left the cluster. The caller must hold n.mu.
+func (n *NetworkVideoContentService) nodeByAddressLocked(addr string) (*Node, error) {
+	if node := n.ring.lookup(addr); node != nil {
+		return node, nil
+	}
+	if node, ok := n.draining[addr]; ok {
+		return node, nil
+	}
+	for _, r := range n.prevRings {
+		if node := r.lookup(addr); node != nil {
+			return node, nil
+		}
+	}
+	return newNode(addr, n.virtualNodes, 1, nodeLabels{})
+}
@@ -0,0 +1,209 @@
+package web
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"tritontube/internal/keyspace"
+)
+
+// writeJournal writes entries, one per line, followed by tail as the
+// journal in dir.
+func writeJournal(t *testing.T, dir string, tail string, entries ...journalEntry) {
+	t.Helper()
+	var b strings.Builder
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(append(data, '\n'))
+	}
+	b.WriteString(tail)
+	if err := os.WriteFile(filepath.Join(dir, "journal.log"), []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenJournal(t *testing.T) {
+	begin := func(id int64) journalEntry {
+		return journalEntry{ID: id, Op: journalBegin, Key: "video/manifest.mpd", Source: "a:9000", CopyTo: []string{"b:9000"}}
+	}
+	mark := func(id int64, op string) journalEntry {
+		return journalEntry{ID: id, Op: op}
+	}
+	tests := []struct {
+		name    string
+		entries []journalEntry
+		tail    string
+		// pending maps each open move to the last step it reached.
+		pending map[int64]string
+		next    int64
+	}{
+		{"empty", nil, "", map[int64]string{}, 0},
+		{"begun", []journalEntry{begin(0)}, "", map[int64]string{0: journalBegin}, 1},
+		{"copied", []journalEntry{begin(0), mark(0, journalCopied)}, "", map[int64]string{0: journalCopied}, 1},
+		{"done", []journalEntry{begin(0), mark(0, journalCopied), mark(0, journalDone)}, "", map[int64]string{}, 1},
+		{"aborted", []journalEntry{begin(0), mark(0, journalAborted)}, "", map[int64]string{}, 1},
+		{"interleaved", []journalEntry{begin(0), begin(1), begin(2), mark(1, journalCopied), mark(0, journalDone)}, "", map[int64]string{1: journalCopied, 2: journalBegin}, 3},
+		{"mark without begin", []journalEntry{mark(4, journalCopied)}, "", map[int64]string{}, 5},
+		{"torn begin", []journalEntry{begin(0)}, `{"id":1,"op":"beg`, map[int64]string{0: journalBegin}, 1},
+		{"torn copied", []journalEntry{begin(0)}, `{"id":0,"op":"cop`, map[int64]string{0: journalBegin}, 1},
+		{"torn done", []journalEntry{begin(0), mark(0, journalCopied)}, `{"id":0,"op":"done"`, map[int64]string{0: journalCopied}, 1},
+		{"only torn", nil, `{"id":7`, map[int64]string{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeJournal(t, dir, tt.tail, tt.entries...)
+			j, pending, err := openJournal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer j.file.Close()
+			got := make(map[int64]string)
+			for _, entry := range pending {
+				got[entry.ID] = entry.Op
+				if entry.Key != "video/manifest.mpd" || entry.Source != "a:9000" || !slices.Equal(entry.CopyTo, []string{"b:9000"}) {
+					t.Errorf("move %d lost its details: %+v", entry.ID, entry)
+				}
+			}
+			if len(got) != len(tt.pending) {
+				t.Fatalf("pending moves %v, want %v", got, tt.pending)
+			}
+			for id, op := range tt.pending {
+				if got[id] != op {
+					t.Errorf("move %d reached %q, want %q", id, got[id], op)
+				}
+			}
+			if j.next != tt.next {
+				t.Errorf("next ID %d, want %d", j.next, tt.next)
+			}
+
+			// A move begun after reopening survives the next reopening,
+			// even after a torn line.
+			id, err := j.begin("video/segment.m4s", "a:9000", []string{"b:9000"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			j.file.Close()
+			j, pending, err = openJournal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.ContainsFunc(pending, func(entry *journalEntry) bool {
+				return entry.ID == id && entry.Key == "video/segment.m4s"
+			}) {
+				t.Errorf("move %d begun after reopening was lost", id)
+			}
+		})
+	}
+}
+
+func TestJournalTruncates(t *testing.T) {
+	dir := t.TempDir()
+	j, _, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.file.Close()
+	first, err := j.begin("video/a.m4s", "a:9000", []string{"b:9000"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := j.begin("video/b.m4s", "a:9000", []string{"b:9000"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := func() int64 {
+		info, err := j.file.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+	if err := j.mark(first, journalDone); err != nil {
+		t.Fatal(err)
+	}
+	if size() == 0 {
+		t.Error("journal truncated with a move still open")
+	}
+	if err := j.mark(second, journalAborted); err != nil {
+		t.Fatal(err)
+	}
+	if size() != 0 {
+		t.Errorf("journal is %d bytes with no move open, want 0", size())
+	}
+}
+
+func TestRecoverJournal(t *testing.T) {
+	key := keyspace.Key("video", "manifest.mpd")
+	begin := journalEntry{ID: 0, Op: journalBegin, Key: key, Source: "a:9000", CopyTo: []string{"b:9000"}, DeleteFrom: []string{"a:9000"}}
+	copied := journalEntry{ID: 0, Op: journalCopied}
+	tests := []struct {
+		name    string
+		entries []journalEntry
+		// holders hold the file at startup; ring is the nodes now on the
+		// ring.
+		holders      []string
+		ring         []string
+		refuseWrites bool
+		want         []string
+		open         bool
+	}{
+		{"crash between copied and done", []journalEntry{begin, copied}, []string{"a:9000", "b:9000"}, []string{"b:9000"}, false, []string{"b:9000"}, false},
+		{"crash after the delete", []journalEntry{begin, copied}, []string{"b:9000"}, []string{"b:9000"}, false, []string{"b:9000"}, false},
+		{"crash mid-copy", []journalEntry{begin}, []string{"a:9000"}, []string{"b:9000"}, false, []string{"b:9000"}, false},
+		{"file deleted since", []journalEntry{begin, copied}, nil, []string{"b:9000"}, false, nil, false},
+		{"ring changed since", []journalEntry{begin, copied}, []string{"a:9000", "b:9000"}, []string{"c:9000"}, false, []string{"c:9000"}, false},
+		{"copy fails", []journalEntry{begin}, []string{"a:9000"}, []string{"b:9000"}, true, []string{"a:9000"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeCluster()
+			all := []*Node{c.node("a:9000"), c.node("b:9000"), c.node("c:9000")}
+			for _, addr := range tt.holders {
+				c.storage[addr].put(key, []byte("manifest"))
+			}
+			c.storage["b:9000"].refuseWrites = tt.refuseWrites
+			c.storage["c:9000"].refuseWrites = tt.refuseWrites
+			n := testService(1)
+			for _, node := range all {
+				if slices.Contains(tt.ring, node.address) {
+					n.ring.add(node)
+				}
+			}
+			// The nodes off the ring are known from an earlier ring.
+			prev := newHashRing(ringPlacement{})
+			for _, node := range all {
+				prev.add(node)
+			}
+			n.prevRings = []*hashRing{prev}
+
+			dir := t.TempDir()
+			writeJournal(t, dir, "", tt.entries...)
+			j, pending, err := openJournal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.journal = j
+			n.recoverJournal(pending)
+			j.file.Close()
+
+			if got := c.holders(key); !slices.Equal(got, tt.want) {
+				t.Errorf("%s is on %v, want %v", key, got, tt.want)
+			}
+			j, pending, err = openJournal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			j.file.Close()
+			if open := len(pending) > 0; open != tt.open {
+				t.Errorf("move left open %v, want %v", open, tt.open)
+			}
+		})
+	}
+}
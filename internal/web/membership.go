@@ -0,0 +1,239 @@
+package web
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ringState is the ring membership saved under the state directory so the
+// ring can be rebuilt after a restart.
+type ringState struct {
+	Version int64       `json:"version"`
+	Nodes   []nodeState `json:"nodes"`
+	// Placement names the placement strategy of Nodes; empty means "ring",
+	// the only strategy before there was a choice.
+	Placement string `json:"placement,omitempty"`
+	// Loads are the measured range loads of Nodes, keyed by the token
+	// ending each range.
+	Loads map[uint64]rangeLoad `json:"loads,omitempty"`
+	// PreviousRings are the rings files may still sit on, oldest first.
+	PreviousRings []previousRing `json:"previous_rings,omitempty"`
+
+	// Previous, PreviousPlacement and PreviousLoads are the single previous
+	// ring saved before PreviousRings; they are only read.
+	Previous          []nodeState          `json:"previous,omitempty"`
+	PreviousPlacement string               `json:"previous_placement,omitempty"`
+	PreviousLoads     map[uint64]rangeLoad `json:"previous_loads,omitempty"`
+}
+
+// previousRing is a saved ring from before the migrations in flight, or
+// left unfinished, began.
+type previousRing struct {
+	Nodes     []nodeState          `json:"nodes"`
+	Placement string               `json:"placement"`
+	Loads     map[uint64]rangeLoad `json:"loads,omitempty"`
+}
+
+// savedPlacement returns the placement strategy the saved ring was using.
+func (state *ringState) savedPlacement() string {
+	return cmp.Or(state.Placement, "ring")
+}
+
+type nodeState struct {
+	Address      string     `json:"address"`
+	VirtualNodes int        `json:"virtual_nodes"`
+	Weight       float64    `json:"weight"`
+	Draining     bool       `json:"draining,omitempty"`
+	Labels       nodeLabels `json:"labels,omitzero"`
+}
+
+func ringStatePath(stateDir string) string {
+	return filepath.Join(stateDir, "ring.json")
+}
+
+// loadRingState reads the saved ring membership, returning nil if there is none.
+func loadRingState(stateDir string) (*ringState, error) {
+	if stateDir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(ringStatePath(stateDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &ringState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restoreRing adds the nodes in state to the ring and warns if they differ
+// from the addresses given on the command line, which are ignored.
+func (n *NetworkVideoContentService) restoreRing(state *ringState, addresses []string) (err error) {
+	saved := make([]string, len(state.Nodes))
+	for i, ns := range state.Nodes {
+		node, err := newNode(ns.Address, ns.VirtualNodes, ns.Weight, ns.Labels)
+		if err != nil {
+			return err
+		}
+		if ns.Draining {
+			n.draining[node.address] = node
+		} else {
+			n.ring.add(node)
+		}
+		saved[i] = ns.Address
+	}
+	n.ring.setLoads(state.Loads)
+	n.ringVersion = state.Version
+	placement, err := ParsePlacement(state.savedPlacement())
+	if err != nil {
+		return err
+	}
+	previous := state.PreviousRings
+	if previous == nil && state.Previous != nil {
+		previous = []previousRing{{Nodes: state.Previous, Placement: cmp.Or(state.PreviousPlacement, state.savedPlacement()), Loads: state.PreviousLoads}}
+	}
+	for _, prev := range previous {
+		p, err := ParsePlacement(prev.Placement)
+		if err != nil {
+			return err
+		}
+		r := newHashRing(p)
+		for _, ns := range prev.Nodes {
+			node, err := n.knownNodeLocked(ns)
+			if err != nil {
+				return err
+			}
+			r.add(node)
+		}
+		r.setLoads(prev.Loads)
+		n.prevRings = append(n.prevRings, r)
+	}
+	if placement.Name() != n.ring.placement.Name() && n.prevRings == nil {
+		// Files stay where the saved strategy put them until the
+		// rebalance started by NewNetworkVideoContentService moves them.
+		r := n.ring.clone()
+		r.placement = placement
+		n.prevRings = []*hashRing{r}
+	}
+	slices.Sort(saved)
+	given := slices.Sorted(slices.Values(addresses))
+	if !slices.Equal(slices.Compact(given), saved) {
+		log.Printf("Warning: storage nodes %v differ from saved ring membership %v (version %d); using the saved membership\n", given, saved, state.Version)
+	}
+	return nil
+}
+
+// knownNodeLocked returns the node saved as ns, reusing the node of the same
+// address already restored, if any. The caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) knownNodeLocked(ns nodeState) (*Node, error) {
+	if node := n.ring.lookup(ns.Address); node != nil {
+		return node, nil
+	}
+	if node := n.draining[ns.Address]; node != nil {
+		return node, nil
+	}
+	for _, r := range n.prevRings {
+		if node := r.lookup(ns.Address); node != nil {
+			return node, nil
+		}
+	}
+	return newNode(ns.Address, ns.VirtualNodes, ns.Weight, ns.Labels)
+}
+
+// beginTransitionLocked remembers the current ring as a previous ownership
+// before a membership change. Every ring since the cluster last settled is
+// kept, since with several migrations in flight a file may sit on the owners
+// of any of them. The caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) beginTransitionLocked() {
+	n.prevRings = append(n.prevRings, n.ring.clone())
+}
+
+// endTransitionLocked forgets the previous rings once no migration is left
+// in flight. It is only called after a rebalance has finished without
+// errors, since files a failed one left behind are found through the
+// previous rings until then. The caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) endTransitionLocked() {
+	if n.transitions > 0 || n.prevRings == nil {
+		return
+	}
+	n.prevRings = nil
+	n.saveRingLocked()
+}
+
+// addedToPrevRingLocked reports whether the ring is the only previous ring
+// with just node added. The caller must hold n.mu.
+func (n *NetworkVideoContentService) addedToPrevRingLocked(node *Node) bool {
+	if len(n.prevRings) != 1 {
+		return false
+	}
+	prev := n.prevRings[0]
+	if prev.placement.Name() != n.ring.placement.Name() || prev.lookup(node.address) != nil {
+		return false
+	}
+	return slices.Equal(sortedNodes(append(prev.nodes(), node)), sortedNodes(n.ring.nodes()))
+}
+
+// prevNodesLocked returns the nodes on any previous ring. The caller must
+// hold n.mu.
+func (n *NetworkVideoContentService) prevNodesLocked() []*Node {
+	var nodes []*Node
+	for _, r := range n.prevRings {
+		for _, node := range r.nodes() {
+			if !slices.Contains(nodes, node) {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
+
+// ringChangedLocked bumps the ring version and saves the membership. The
+// caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) ringChangedLocked() {
+	n.ringVersion += 1
+	n.saveRingLocked()
+}
+
+func (n *NetworkVideoContentService) saveRingLocked() {
+	if n.stateDir == "" {
+		return
+	}
+	state := ringState{Version: n.ringVersion, Nodes: make([]nodeState, 0), Placement: n.ring.placement.Name(), Loads: n.ring.loads}
+	for _, node := range append(n.ring.nodes(), n.drainingNodesLocked()...) {
+		state.Nodes = append(state.Nodes, nodeState{
+			Address:      node.address,
+			VirtualNodes: node.virtualNodes,
+			Weight:       node.weight,
+			Draining:     n.draining[node.address] == node,
+			Labels:       node.labels,
+		})
+	}
+	for _, r := range n.prevRings {
+		saved := previousRing{Nodes: make([]nodeState, 0), Placement: r.placement.Name(), Loads: r.loads}
+		for _, node := range r.nodes() {
+			saved.Nodes = append(saved.Nodes, nodeState{
+				Address:      node.address,
+				VirtualNodes: node.virtualNodes,
+				Weight:       node.weight,
+				Labels:       node.labels,
+			})
+		}
+		state.PreviousRings = append(state.PreviousRings, saved)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("Save ring: %v\n", err)
+		return
+	}
+	if err := writeStateFile(ringStatePath(n.stateDir), data); err != nil {
+		log.Printf("Save ring: %v\n", err)
+	}
+}
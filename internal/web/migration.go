@@ -0,0 +1,614 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tritontube/internal/fsutil"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Migration operations and job states.
+const (
+	opAdd    = "add"
+	opRemove = "remove"
+	opEject  = "eject"
+	opDrain  = "drain"
+	// opPlacement rebalances every file after the placement strategy or
+	// the range loads it uses change; it has no node.
+	opPlacement = "placement"
+
+	jobPending   = "pending"
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+// maxJobErrors caps how many errors a job keeps for GetMigrationStatus.
+const maxJobErrors = 100
+
+// jobSaveInterval limits how often progress is written to the state directory.
+const jobSaveInterval = time.Second
+
+// migrationJob is a background rebalance started by AddNode, RemoveNode,
+// DrainNode or the ejection of a dead node.
+// Jobs are saved under the state directory so they resume after a restart.
+type migrationJob struct {
+	ID             string     `json:"id"`
+	Operation      string     `json:"operation"`
+	NodeAddress    string     `json:"node_address"`
+	VirtualNodes   int        `json:"virtual_nodes"`
+	Weight         float64    `json:"weight"`
+	Labels         nodeLabels `json:"labels,omitzero"`
+	State          string     `json:"state"`
+	FilesMoved     int64      `json:"files_moved"`
+	BytesMoved     int64      `json:"bytes_moved"`
+	FilesRemaining int64      `json:"files_remaining"`
+	Errors         []string   `json:"errors,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	savedAt time.Time
+}
+
+func (job *migrationJob) finished() bool {
+	return job.State == jobCompleted || job.State == jobFailed
+}
+
+func (job *migrationJob) addError(err error) {
+	if len(job.Errors) < maxJobErrors {
+		job.Errors = append(job.Errors, err.Error())
+	}
+}
+
+// startJob records a new migration job for a membership change that has
+// already been applied to the ring, saves the ring and runs the job in the
+// background. The job is saved first, so that after a crash in between
+// resumeJobs finds it and applies the change again. node is nil for
+// opPlacement. The caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) startJob(operation string, node *Node) *migrationJob {
+	job := &migrationJob{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		Operation: operation,
+		State:     jobPending,
+		CreatedAt: time.Now(),
+	}
+	if node != nil {
+		job.NodeAddress = node.address
+		job.VirtualNodes = node.virtualNodes
+		job.Weight = node.weight
+		job.Labels = node.labels
+	}
+	n.jobsMu.Lock()
+	n.jobs[job.ID] = job
+	n.saveJobLocked(job)
+	n.jobsMu.Unlock()
+	n.ringChangedLocked()
+	n.transitions += 1
+	go n.runJob(job, node)
+	return job
+}
+
+// runJob rebalances the cluster for job. Jobs run one at a time; since a
+// rebalance always converges on the current ring, running queued jobs in any
+// order gives the same result. node is nil if the job has none.
+func (n *NetworkVideoContentService) runJob(job *migrationJob, node *Node) {
+	n.migrationMu.Lock()
+	defer n.migrationMu.Unlock()
+	n.updateJob(job, true, func() {
+		job.State = jobRunning
+	})
+
+	// Draining nodes take part as sources so their files keep moving off.
+	n.mu.RLock()
+	nodes := append(n.ring.nodes(), n.drainingNodesLocked()...)
+	// Nodes that have left the ring since a previous one may still hold
+	// files that were never copied off them, so they take part as sources
+	// too, as does the node a remove job took off the ring.
+	departed := slices.DeleteFunc(n.prevNodesLocked(), func(node *Node) bool {
+		return slices.Contains(nodes, node)
+	})
+	if job.Operation == opRemove && node != nil && !slices.Contains(nodes, node) && !slices.Contains(departed, node) {
+		departed = append(departed, node)
+	}
+	// Down nodes cannot be listed; their files are treated as missing.
+	nodes = slices.DeleteFunc(nodes, isDown)
+	// A node joining a settled cluster only takes over keys in a few ring
+	// ranges, so only those ranges are listed, on the nodes that held them.
+	// With other migrations in flight, or files left behind by one that
+	// failed, files may still sit on older owners, so everything is listed
+	// instead.
+	takeover := job.Operation == opAdd && node != nil && n.transitions == 1 && n.ring.lookup(node.address) == node &&
+		n.addedToPrevRingLocked(node) && canTakeOver(n.ring)
+	var ranges []keyRange
+	var sources []*Node
+	if takeover {
+		ranges, sources = n.takeoverRanges(n.ring, node)
+		sources = slices.DeleteFunc(append(sources, node), func(node *Node) bool {
+			return !slices.Contains(nodes, node)
+		})
+		sources = append(sources, n.drainingNodesLocked()...)
+	}
+	n.mu.RUnlock()
+	// Departed nodes are no longer health checked, so they are checked now.
+	// Like an ejected node, one that is down is left out.
+	for _, node := range departed {
+		if checkNode(node, probeTimeout) {
+			nodes = append(nodes, node)
+		} else {
+			log.Printf("Migration %s: %s is not responding; files only it holds are not restored\n", job.ID, node.address)
+		}
+	}
+
+	// A node that cannot be listed is left out of the rest of the walk and
+	// the job fails, so the previous rings are kept for reads.
+	skip := func(err error) {
+		n.updateJob(job, true, func() {
+			job.addError(err)
+		})
+	}
+	walk := walkAll(context.Background(), nodes, skip)
+	if takeover {
+		walk = walkRanges(context.Background(), sources, ranges, skip)
+	}
+	err := n.rebalance(job, walk)
+
+	n.updateJob(job, true, func() {
+		if err != nil {
+			job.addError(err)
+		}
+		if len(job.Errors) > 0 {
+			job.State = jobFailed
+		} else {
+			job.State = jobCompleted
+		}
+	})
+	log.Printf("Migration %s (%s) %s: %d files, %d bytes moved\n", job.ID, strings.TrimSpace(job.Operation+" "+job.NodeAddress), job.State, job.FilesMoved, job.BytesMoved)
+
+	n.mu.Lock()
+	n.transitions -= 1
+	if job.State == jobCompleted {
+		n.endTransitionLocked()
+	}
+	n.mu.Unlock()
+}
+
+// probeTimeout bounds the health check of a node that is not monitored, such
+// as one that has left the ring or is about to join it, before it is listed.
+const probeTimeout = 5 * time.Second
+
+// RetryMigration runs a failed job again. Files the job could not move stay
+// where they were, and readable through the previous rings, until a rebalance
+// finishes without errors.
+func (n *NetworkVideoContentService) RetryMigration(ctx context.Context, req *proto.RetryMigrationRequest) (*proto.RetryMigrationResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.jobsMu.Lock()
+	job, ok := n.jobs[req.JobId]
+	if !ok {
+		n.jobsMu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "no migration job %q", req.JobId)
+	}
+	if job.State != jobFailed {
+		n.jobsMu.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "migration job %s is %s; only failed jobs can be retried", job.ID, job.State)
+	}
+	job.State = jobPending
+	job.Errors = nil
+	job.FilesRemaining = 0
+	n.saveJobLocked(job)
+	n.jobsMu.Unlock()
+
+	var node *Node
+	if job.NodeAddress != "" {
+		var err error
+		if node, err = n.nodeByAddressLocked(job.NodeAddress); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("Retrying migration %s (%s)\n", job.ID, strings.TrimSpace(job.Operation+" "+job.NodeAddress))
+	n.transitions += 1
+	go n.runJob(job, node)
+	return &proto.RetryMigrationResponse{}, nil
+}
+
+// updateJob applies fn to job under jobsMu and saves it, at most once per
+// jobSaveInterval unless force is set.
+func (n *NetworkVideoContentService) updateJob(job *migrationJob, force bool, fn func()) {
+	n.jobsMu.Lock()
+	defer n.jobsMu.Unlock()
+	fn()
+	if force || time.Since(job.savedAt) >= jobSaveInterval {
+		n.saveJobLocked(job)
+	}
+}
+
+func (n *NetworkVideoContentService) saveJobLocked(job *migrationJob) {
+	job.savedAt = time.Now()
+	if n.stateDir == "" {
+		return
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Printf("Save migration %s: %v\n", job.ID, err)
+		return
+	}
+	if err := writeStateFile(filepath.Join(n.stateDir, "migrations", job.ID+".json"), data); err != nil {
+		log.Printf("Save migration %s: %v\n", job.ID, err)
+	}
+}
+
+// writeStateFile replaces path with data so that a crash never leaves a
+// partially written file behind, and syncs it so it survives one.
+func writeStateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data, 0644, false)
+}
+
+// resumeJobs loads saved migration jobs and restarts the unfinished ones,
+// reapplying their membership change if the ring does not reflect it. The
+// caller must hold n.mu for writing.
+func (n *NetworkVideoContentService) resumeJobs() error {
+	if n.stateDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(n.stateDir, "migrations"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pending []*migrationJob
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(n.stateDir, "migrations", entry.Name()))
+		if err != nil {
+			return err
+		}
+		job := &migrationJob{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		n.jobs[job.ID] = job
+		if !job.finished() {
+			pending = append(pending, job)
+		}
+	}
+	slices.SortFunc(pending, func(a, b *migrationJob) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	for _, job := range pending {
+		node := n.ring.lookup(job.NodeAddress)
+		switch {
+		case job.Operation == opAdd && node == nil:
+			if node, err = newNode(job.NodeAddress, job.VirtualNodes, job.Weight, job.Labels); err != nil {
+				return err
+			}
+			n.beginTransitionLocked()
+			n.ring.add(node)
+			n.ringChangedLocked()
+		case (job.Operation == opRemove || job.Operation == opEject) && node != nil:
+			n.beginTransitionLocked()
+			n.ring.remove(node)
+			n.ringChangedLocked()
+		case job.Operation == opRemove || job.Operation == opEject:
+			if node, err = newNode(job.NodeAddress, job.VirtualNodes, job.Weight, job.Labels); err != nil {
+				return err
+			}
+		case job.Operation == opDrain && node != nil:
+			n.beginTransitionLocked()
+			n.ring.remove(node)
+			n.draining[node.address] = node
+			n.ringChangedLocked()
+		case job.Operation == opDrain:
+			if node = n.draining[job.NodeAddress]; node == nil {
+				// The drained node was already removed.
+				job.State = jobCompleted
+				n.saveJobLocked(job)
+				continue
+			}
+		}
+		log.Printf("Resuming migration %s (%s %s)\n", job.ID, job.Operation, job.NodeAddress)
+		n.transitions += 1
+		go n.runJob(job, node)
+	}
+	return nil
+}
+
+// heldFile is a file found on one or more nodes while listing the cluster.
+type heldFile struct {
+	holders []*Node
+	size    int64
+}
+
+// fileMove is a file whose holders differ from the replica set the ring
+// assigns to it.
+type fileMove struct {
+	key  string
+	size int64
+	have []*Node
+	want []*Node
+}
+
+// listPageSize is the number of files asked for in each ListFiles call.
+const listPageSize = 1000
+
+// fileLister pages through the files held by one node.
+type fileLister struct {
+	node  *Node
+	fetch func(token string) (*proto.ListFilesResponse, error)
+	page  []*proto.FileInfo
+	token string
+	done  bool
+}
+
+// listAll lists every file on node.
+func listAll(ctx context.Context, node *Node) *fileLister {
+	return &fileLister{node: node, fetch: func(token string) (*proto.ListFilesResponse, error) {
+		return node.client.ListFiles(ctx, &proto.ListFilesRequest{PageToken: token, PageSize: listPageSize})
+	}}
+}
+
+// peek returns the next file on the node without consuming it, or nil once
+// every file has been listed.
+func (l *fileLister) peek() (*proto.FileInfo, error) {
+	for len(l.page) == 0 && !l.done {
+		resp, err := l.fetch(l.token)
+		if err != nil {
+			return nil, fmt.Errorf("list files on %s: %w", l.node.address, err)
+		}
+		l.page = resp.Files
+		l.token = resp.NextPageToken
+		l.done = l.token == ""
+	}
+	if len(l.page) == 0 {
+		return nil, nil
+	}
+	return l.page[0], nil
+}
+
+// holderWalk calls fn with each file held by some set of nodes and the nodes
+// holding it.
+type holderWalk func(fn func(key string, file *heldFile) error) error
+
+// walkHolders merges the listings of several nodes, which must all list
+// files in the order given by compare, and calls fn with each file and the
+// nodes holding it. Nodes are listed a page at a time, so memory use does not
+// grow with the number of files. Every node's next page is fetched before fn
+// runs, so fn may copy the file it is given to other nodes without it being
+// listed again. If skip is nil, a node that fails to list ends the walk;
+// otherwise the error is passed to skip and the walk goes on without it.
+func walkHolders(listers []*fileLister, compare func(a string, b string) int, fn func(key string, file *heldFile) error, skip func(error)) error {
+	// peekAll makes sure every node's next page is fetched and returns the
+	// first file left on any node.
+	peekAll := func() (*proto.FileInfo, error) {
+		var next *proto.FileInfo
+		for i := 0; i < len(listers); {
+			info, err := listers[i].peek()
+			if err != nil {
+				if skip == nil {
+					return nil, err
+				}
+				skip(err)
+				listers = slices.Delete(listers, i, i+1)
+				continue
+			}
+			if info != nil && (next == nil || compare(info.Name, next.Name) < 0) {
+				next = info
+			}
+			i++
+		}
+		return next, nil
+	}
+	next, err := peekAll()
+	if err != nil {
+		return err
+	}
+	for next != nil {
+		file := &heldFile{size: next.Size}
+		for _, l := range listers {
+			if len(l.page) > 0 && l.page[0].Name == next.Name {
+				file.holders = append(file.holders, l.node)
+				l.page = l.page[1:]
+			}
+		}
+		key := next.Name
+		if next, err = peekAll(); err != nil {
+			return err
+		}
+		if err := fn(key, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkAll walks every file on nodes. skip is as for walkHolders.
+func walkAll(ctx context.Context, nodes []*Node, skip func(error)) holderWalk {
+	return func(fn func(key string, file *heldFile) error) error {
+		listers := make([]*fileLister, len(nodes))
+		for i, node := range nodes {
+			listers[i] = listAll(ctx, node)
+		}
+		return walkHolders(listers, keyspace.Compare, fn, skip)
+	}
+}
+
+// planMove reports whether file is not exactly on its replica set on r, and
+// if so how it has to move. A file is never moved off its holders when r has
+// no node to take it. The caller must hold n.mu if r is n.ring.
+func (n *NetworkVideoContentService) planMove(key string, file *heldFile, r *hashRing) (fileMove, bool) {
+	want := r.keyReplicas(key, n.replicationFactor)
+	if len(want) == 0 || slices.Equal(sortedNodes(file.holders), sortedNodes(want)) {
+		return fileMove{}, false
+	}
+	return fileMove{key: key, size: file.size, have: file.holders, want: want}, true
+}
+
+// rebalance copies every file visited by walk onto the replica set chosen by
+// the current ring and deletes it from holders outside that set. Failures on
+// individual files are recorded on job and do not stop the rebalance.
+func (n *NetworkVideoContentService) rebalance(job *migrationJob, walk holderWalk) error {
+	// The files to move are counted in a first pass so that the job's
+	// remaining count means something; listing is cheap next to copying.
+	remaining := int64(0)
+	err := walk(func(key string, file *heldFile) error {
+		n.mu.RLock()
+		_, ok := n.planMove(key, file, n.ring)
+		n.mu.RUnlock()
+		if ok {
+			remaining += 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	n.updateJob(job, true, func() {
+		job.FilesRemaining = remaining
+	})
+	// Moves run on a pool of workers sized by n.throttle. The walk has
+	// fetched every node's listing past a file before handing it out, so
+	// moving it concurrently cannot make it show up again.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	return walk(func(key string, file *heldFile) error {
+		n.mu.RLock()
+		m, ok := n.planMove(key, file, n.ring)
+		n.mu.RUnlock()
+		if !ok {
+			return nil
+		}
+		n.throttle.acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer n.throttle.release()
+			n.moveFile(job, m)
+		}()
+		return nil
+	})
+}
+
+// moveFile copies a file to the replicas missing it and then deletes it from
+// the nodes that should no longer hold it, journaling each step. Copies are
+// paced by n.throttle.
+func (n *NetworkVideoContentService) moveFile(job *migrationJob, m fileMove) {
+	copyTo, deleteFrom := m.changes()
+	n.throttle.pace(m.size * int64(len(copyTo)))
+	var copied, bytesCopied int64
+	id, err := n.journal.begin(m.key, m.have[0].address, nodeAddresses(copyTo), nodeAddresses(deleteFrom))
+	if err == nil {
+		copied, bytesCopied, err = n.applyMove(m, id)
+		if err != nil {
+			// The file is left for the next migration to plan again, so
+			// the move is closed rather than replayed on startup.
+			if abortErr := n.journal.mark(id, journalAborted); abortErr != nil {
+				log.Printf("Journal: abort move %d of %s: %v\n", id, m.key, abortErr)
+			}
+		}
+	}
+	n.updateJob(job, false, func() {
+		job.FilesMoved += copied
+		job.BytesMoved += bytesCopied
+		job.FilesRemaining = max(0, job.FilesRemaining-1)
+		if err != nil {
+			job.addError(err)
+		}
+	})
+}
+
+// changes returns the nodes m's file must be copied to and the nodes it must
+// then be deleted from.
+func (m fileMove) changes() ([]*Node, []*Node) {
+	var copyTo, deleteFrom []*Node
+	for _, node := range m.want {
+		if !slices.Contains(m.have, node) {
+			copyTo = append(copyTo, node)
+		}
+	}
+	for _, node := range m.have {
+		if !slices.Contains(m.want, node) {
+			deleteFrom = append(deleteFrom, node)
+		}
+	}
+	return copyTo, deleteFrom
+}
+
+// applyMove carries out m, marking journal entry id copied and then done,
+// and returns the number of copies made and bytes copied.
+func (n *NetworkVideoContentService) applyMove(m fileMove, id int64) (int64, int64, error) {
+	videoId, filename := keyspace.Split(m.key)
+	copyTo, deleteFrom := m.changes()
+	var copied, bytesCopied int64
+	for _, successor := range copyTo {
+		size, err := transferFile(context.Background(), m.have[0], successor, videoId, filename)
+		if err != nil {
+			return copied, bytesCopied, fmt.Errorf("copy %s to %s: %w", m.key, successor.address, err)
+		}
+		copied += 1
+		bytesCopied += size
+	}
+	if err := n.journal.mark(id, journalCopied); err != nil {
+		return copied, bytesCopied, err
+	}
+	// Only drop extra copies once every replica is in place.
+	for _, node := range deleteFrom {
+		_, err := node.client.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: videoId, Filename: filename})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return copied, bytesCopied, fmt.Errorf("delete %s from %s: %w", m.key, node.address, err)
+		}
+	}
+	return copied, bytesCopied, n.journal.mark(id, journalDone)
+}
+
+// nodeAddresses returns the addresses of nodes.
+func nodeAddresses(nodes []*Node) []string {
+	addrs := make([]string, len(nodes))
+	for i, node := range nodes {
+		addrs[i] = node.address
+	}
+	return addrs
+}
+
+// sortedNodes returns the addresses of nodes in sorted order.
+func sortedNodes(nodes []*Node) []string {
+	addrs := nodeAddresses(nodes)
+	slices.Sort(addrs)
+	return addrs
+}
+
+func (n *NetworkVideoContentService) GetMigrationStatus(ctx context.Context, req *proto.GetMigrationStatusRequest) (*proto.GetMigrationStatusResponse, error) {
+	n.jobsMu.Lock()
+	defer n.jobsMu.Unlock()
+	job, ok := n.jobs[req.JobId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no migration job %q", req.JobId)
+	}
+	return &proto.GetMigrationStatusResponse{
+		JobId:          job.ID,
+		Operation:      job.Operation,
+		NodeAddress:    job.NodeAddress,
+		State:          job.State,
+		FilesMoved:     job.FilesMoved,
+		BytesMoved:     job.BytesMoved,
+		FilesRemaining: job.FilesRemaining,
+		Errors:         slices.Clone(job.Errors),
+	}, nil
+}
@@ -0,0 +1,457 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeCluster is a set of in-memory storage nodes that can transfer files to
+// one another.
+type fakeCluster struct {
+	storage map[string]*fakeStorage
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{storage: make(map[string]*fakeStorage)}
+}
+
+// node returns a node backed by a new in-memory storage node.
+func (c *fakeCluster) node(addr string) *Node {
+	s := &fakeStorage{cluster: c, files: make(map[string][]byte)}
+	c.storage[addr] = s
+	return &Node{address: addr, client: s, virtualNodes: 8, weight: 1, health: healthUp}
+}
+
+// holders returns the sorted addresses of the nodes holding key.
+func (c *fakeCluster) holders(key string) []string {
+	var addrs []string
+	for addr, s := range c.storage {
+		if s.has(key) {
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.Sort(addrs)
+	return addrs
+}
+
+// fakeStorage is a StorageServiceClient holding files in memory. Only the
+// calls migrations make are implemented.
+type fakeStorage struct {
+	proto.StorageServiceClient
+	cluster *fakeCluster
+
+	mu    sync.Mutex
+	files map[string][]byte
+	// refuseWrites fails every transfer to this node.
+	refuseWrites bool
+}
+
+func (s *fakeStorage) put(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = data
+}
+
+func (s *fakeStorage) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.files[key]
+	return ok
+}
+
+func (s *fakeStorage) ListFiles(ctx context.Context, req *proto.ListFilesRequest, opts ...grpc.CallOption) (*proto.ListFilesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.files {
+		if req.PageToken == "" || keyspace.Compare(name, req.PageToken) > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, keyspace.Compare)
+	resp := &proto.ListFilesResponse{}
+	if len(names) > int(req.PageSize) {
+		names = names[:req.PageSize]
+		resp.NextPageToken = names[len(names)-1]
+	}
+	for _, name := range names {
+		resp.Filenames = append(resp.Filenames, name)
+		resp.Files = append(resp.Files, &proto.FileInfo{Name: name, Size: int64(len(s.files[name]))})
+	}
+	return resp, nil
+}
+
+func (s *fakeStorage) StatFile(ctx context.Context, req *proto.StatFileRequest, opts ...grpc.CallOption) (*proto.StatFileResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[keyspace.Key(req.VideoId, req.Filename)]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "file not found")
+	}
+	return &proto.StatFileResponse{Size: int64(len(data))}, nil
+}
+
+func (s *fakeStorage) DeleteFile(ctx context.Context, req *proto.DeleteFileRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := keyspace.Key(req.VideoId, req.Filename)
+	if _, ok := s.files[key]; !ok {
+		return nil, status.Error(codes.NotFound, "file not found")
+	}
+	delete(s.files, key)
+	return &proto.Empty{}, nil
+}
+
+func (s *fakeStorage) TransferFiles(ctx context.Context, req *proto.TransferFilesRequest, opts ...grpc.CallOption) (*proto.TransferFilesResponse, error) {
+	dst := s.cluster.storage[req.Destination]
+	resp := &proto.TransferFilesResponse{}
+	for _, name := range req.Filenames {
+		s.mu.Lock()
+		data, ok := s.files[name]
+		s.mu.Unlock()
+		result := &proto.TransferResult{Name: name, Bytes: int64(len(data))}
+		switch {
+		case !ok:
+			result.Error = "file not found"
+		case dst == nil || dst.refuseWrites:
+			result.Error = "write refused"
+		default:
+			dst.put(name, data)
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// testService returns a service with the given nodes on a consistent hashing
+// ring.
+func testService(replicationFactor int, nodes ...*Node) *NetworkVideoContentService {
+	n := &NetworkVideoContentService{
+		ring:              newHashRing(ringPlacement{}),
+		draining:          make(map[string]*Node),
+		replicationFactor: replicationFactor,
+		virtualNodes:      8,
+		jobs:              make(map[string]*migrationJob),
+		throttle:          newMigrationThrottle(4, 0),
+	}
+	for _, node := range nodes {
+		n.ring.add(node)
+	}
+	return n
+}
+
+// testKeys returns count file keys spread over a few videos.
+func testKeys(count int) []string {
+	keys := make([]string, count)
+	for i := range keys {
+		keys[i] = keyspace.Key(fmt.Sprintf("v%d", i%5), fmt.Sprintf("f%d.m4s", i))
+	}
+	return keys
+}
+
+func TestPlanMove(t *testing.T) {
+	c := newFakeCluster()
+	nodes := []*Node{c.node("a:9000"), c.node("b:9000"), c.node("c:9000")}
+	n := testService(2, nodes...)
+	key := keyspace.Key("video", "manifest.mpd")
+	want := n.ring.keyReplicas(key, 2)
+	other := slices.DeleteFunc(slices.Clone(nodes), func(node *Node) bool {
+		return slices.Contains(want, node)
+	})[0]
+
+	tests := []struct {
+		name       string
+		ring       *hashRing
+		holders    []*Node
+		move       bool
+		copyTo     []*Node
+		deleteFrom []*Node
+	}{
+		{"on replicas", n.ring, want, false, nil, nil},
+		{"on replicas in another order", n.ring, []*Node{want[1], want[0]}, false, nil, nil},
+		{"missing a replica", n.ring, want[:1], true, want[1:], nil},
+		{"extra holder", n.ring, append([]*Node{other}, want...), true, nil, []*Node{other}},
+		{"on another node", n.ring, []*Node{other}, true, want, []*Node{other}},
+		{"empty ring", newHashRing(ringPlacement{}), []*Node{other}, false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := n.planMove(key, &heldFile{holders: tt.holders, size: 10}, tt.ring)
+			if ok != tt.move {
+				t.Fatalf("planMove moves %v, want %v", ok, tt.move)
+			}
+			if !ok {
+				return
+			}
+			if m.key != key || m.size != 10 {
+				t.Errorf("move of %s, %d bytes; want %s, 10 bytes", m.key, m.size, key)
+			}
+			copyTo, deleteFrom := m.changes()
+			if !slices.Equal(sortedNodes(copyTo), sortedNodes(tt.copyTo)) {
+				t.Errorf("copies to %v, want %v", nodeAddresses(copyTo), nodeAddresses(tt.copyTo))
+			}
+			if !slices.Equal(sortedNodes(deleteFrom), sortedNodes(tt.deleteFrom)) {
+				t.Errorf("deletes from %v, want %v", nodeAddresses(deleteFrom), nodeAddresses(tt.deleteFrom))
+			}
+		})
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	tests := []struct {
+		name              string
+		replicationFactor int
+		// place returns the nodes holding key before the rebalance.
+		place func(key string, nodes []*Node) []*Node
+	}{
+		{"all on one node", 2, func(key string, nodes []*Node) []*Node {
+			return nodes[:1]
+		}},
+		{"on every node", 2, func(key string, nodes []*Node) []*Node {
+			return nodes
+		}},
+		{"placed before a node was added", 2, func(key string, nodes []*Node) []*Node {
+			old := newHashRing(ringPlacement{})
+			for _, node := range nodes[:3] {
+				old.add(node)
+			}
+			return old.keyReplicas(key, 2)
+		}},
+		{"placed before a node was removed", 1, func(key string, nodes []*Node) []*Node {
+			old := newHashRing(ringPlacement{})
+			for _, node := range nodes {
+				old.add(node)
+			}
+			old.add(&Node{address: "gone:9000", virtualNodes: 8, weight: 1})
+			return slices.DeleteFunc(old.keyReplicas(key, 1), func(node *Node) bool {
+				return node.address == "gone:9000"
+			})
+		}},
+		{"already placed", 3, func(key string, nodes []*Node) []*Node {
+			r := newHashRing(ringPlacement{})
+			for _, node := range nodes {
+				r.add(node)
+			}
+			return r.keyReplicas(key, 3)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeCluster()
+			nodes := []*Node{c.node("a:9000"), c.node("b:9000"), c.node("c:9000"), c.node("d:9000")}
+			n := testService(tt.replicationFactor, nodes...)
+			keys := testKeys(200)
+			moves := int64(0)
+			copies := int64(0)
+			for _, key := range keys {
+				holders := tt.place(key, nodes)
+				if len(holders) == 0 {
+					// The file was only on the removed node.
+					holders = nodes[:1]
+				}
+				for _, node := range holders {
+					c.storage[node.address].put(key, []byte(key))
+				}
+				m, ok := n.planMove(key, &heldFile{holders: holders}, n.ring)
+				if ok {
+					moves += 1
+					copyTo, _ := m.changes()
+					copies += int64(len(copyTo))
+				}
+			}
+
+			job := &migrationJob{ID: "test"}
+			if err := n.rebalance(job, walkAll(context.Background(), nodes, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if len(job.Errors) > 0 {
+				t.Fatalf("rebalance errors: %v", job.Errors)
+			}
+			for _, key := range keys {
+				want := sortedNodes(n.ring.keyReplicas(key, tt.replicationFactor))
+				if got := c.holders(key); !slices.Equal(got, want) {
+					t.Errorf("%s is on %v, want %v", key, got, want)
+				}
+			}
+			if job.FilesMoved != copies || job.FilesRemaining != 0 {
+				t.Errorf("job moved %d files with %d remaining, want %d moved of %d planned", job.FilesMoved, job.FilesRemaining, copies, moves)
+			}
+		})
+	}
+}
+
+func TestApplyMove(t *testing.T) {
+	c := newFakeCluster()
+	a, b, d := c.node("a:9000"), c.node("b:9000"), c.node("d:9000")
+	n := testService(2, a, b)
+	key := keyspace.Key("video", "manifest.mpd")
+	c.storage[d.address].put(key, []byte("manifest"))
+
+	m := fileMove{key: key, size: 8, have: []*Node{d}, want: []*Node{a, b}}
+	copied, bytesCopied, err := n.applyMove(m, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 2 || bytesCopied != 16 {
+		t.Errorf("applyMove copied %d files, %d bytes; want 2 files, 16 bytes", copied, bytesCopied)
+	}
+	if got, want := c.holders(key), []string{"a:9000", "b:9000"}; !slices.Equal(got, want) {
+		t.Errorf("%s is on %v, want %v", key, got, want)
+	}
+}
+
+// TestRebalanceFailedCopy checks that a file whose copy fails stays on the
+// node holding it and that the job reports the failure.
+func TestRebalanceFailedCopy(t *testing.T) {
+	c := newFakeCluster()
+	a, b := c.node("a:9000"), c.node("b:9000")
+	c.storage[b.address].refuseWrites = true
+	n := testService(1, b)
+	journal, _, err := openJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.journal = journal
+	keys := testKeys(20)
+	for _, key := range keys {
+		c.storage[a.address].put(key, []byte(key))
+	}
+
+	job := &migrationJob{ID: "test"}
+	if err := n.rebalance(job, walkAll(context.Background(), []*Node{a, b}, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Errors) != len(keys) {
+		t.Errorf("job has %d errors, want %d", len(job.Errors), len(keys))
+	}
+	if job.FilesMoved != 0 {
+		t.Errorf("job moved %d files, want 0", job.FilesMoved)
+	}
+	for _, key := range keys {
+		if got := c.holders(key); !slices.Equal(got, []string{"a:9000"}) {
+			t.Errorf("%s is on %v, want it left on a:9000", key, got)
+		}
+	}
+	// Every failed move was aborted, so none is replayed.
+	if len(journal.open) != 0 {
+		t.Errorf("%d moves left open in the journal", len(journal.open))
+	}
+}
+
+func TestRemoveLastNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(n *NetworkVideoContentService, addr string) error
+	}{
+		{"remove", func(n *NetworkVideoContentService, addr string) error {
+			_, err := n.RemoveNode(context.Background(), &proto.RemoveNodeRequest{NodeAddress: addr})
+			return err
+		}},
+		{"drain", func(n *NetworkVideoContentService, addr string) error {
+			_, err := n.DrainNode(context.Background(), &proto.DrainNodeRequest{NodeAddress: addr})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeCluster()
+			n := testService(2, c.node("a:9000"))
+			err := tt.remove(n, "a:9000")
+			if status.Code(err) != codes.FailedPrecondition {
+				t.Fatalf("taking off the last node: %v, want %v", err, codes.FailedPrecondition)
+			}
+			if n.ring.lookup("a:9000") == nil || len(n.draining) > 0 || n.prevRings != nil {
+				t.Error("the ring changed")
+			}
+			if len(n.jobs) > 0 {
+				t.Error("a migration job was started")
+			}
+		})
+	}
+}
+
+// waitForJob waits for job to finish and returns its final state.
+func waitForJob(t *testing.T, n *NetworkVideoContentService, job *migrationJob) migrationJob {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		n.jobsMu.Lock()
+		if job.finished() {
+			state := *job
+			n.jobsMu.Unlock()
+			return state
+		}
+		n.jobsMu.Unlock()
+	}
+	t.Fatalf("migration %s did not finish", job.ID)
+	return migrationJob{}
+}
+
+func TestResumeJobs(t *testing.T) {
+	c := newFakeCluster()
+	a, b := c.node("a:9000"), c.node("b:9000")
+	n := testService(1, a, b)
+	n.stateDir = t.TempDir()
+	keys := testKeys(50)
+	for _, key := range keys {
+		c.storage[a.address].put(key, []byte(key))
+	}
+
+	// An add of b that was running when the web server stopped, a drain of
+	// a node that has since been removed, and a job that had finished.
+	jobs := []*migrationJob{
+		{ID: "add", Operation: opAdd, NodeAddress: b.address, VirtualNodes: 8, Weight: 1, State: jobRunning, CreatedAt: time.Now()},
+		{ID: "drain", Operation: opDrain, NodeAddress: "gone:9000", VirtualNodes: 8, Weight: 1, State: jobPending, CreatedAt: time.Now()},
+		{ID: "done", Operation: opAdd, NodeAddress: a.address, State: jobCompleted, CreatedAt: time.Now()},
+	}
+	for _, job := range jobs {
+		data, err := json.Marshal(job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeStateFile(filepath.Join(n.stateDir, "migrations", job.ID+".json"), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A stray file in the directory is not a job.
+	if err := os.WriteFile(filepath.Join(n.stateDir, "migrations", "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n.mu.Lock()
+	err := n.resumeJobs()
+	n.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.jobs) != len(jobs) {
+		t.Fatalf("loaded %d jobs, want %d", len(n.jobs), len(jobs))
+	}
+	if state := waitForJob(t, n, n.jobs["add"]); state.State != jobCompleted || len(state.Errors) > 0 {
+		t.Errorf("add job %s with errors %v, want %s", state.State, state.Errors, jobCompleted)
+	}
+	for _, key := range keys {
+		want := sortedNodes(n.ring.keyReplicas(key, 1))
+		if got := c.holders(key); !slices.Equal(got, want) {
+			t.Errorf("%s is on %v, want %v", key, got, want)
+		}
+	}
+	if state := waitForJob(t, n, n.jobs["drain"]); state.State != jobCompleted {
+		t.Errorf("drain job of a removed node %s, want %s", state.State, jobCompleted)
+	}
+	if n.jobs["done"].State != jobCompleted {
+		t.Errorf("finished job is %s, want %s", n.jobs["done"].State, jobCompleted)
+	}
+}
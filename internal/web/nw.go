@@ -4,164 +4,315 @@ package web
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net"
-	"sort"
-	"strings"
+	"os"
+	"slices"
 	"sync"
+	"time"
+	"tritontube/internal/keyspace"
 	"tritontube/internal/proto"
-
-	"slices"
+	"tritontube/internal/safepath"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-func hashStringToUint64(s string) uint64 {
-	sum := sha256.Sum256([]byte(s))
-	return binary.BigEndian.Uint64(sum[:8])
+// NetworkConfig holds the tunable settings of a NetworkVideoContentService.
+type NetworkConfig struct {
+	// ReplicationFactor is the number of distinct nodes each file is stored on.
+	ReplicationFactor int
+	// VirtualNodes is the number of ring tokens given to a node of weight 1
+	// when AddNodeRequest does not specify one.
+	VirtualNodes int
+	// StateDir is where ring membership, migration jobs and the migration
+	// journal are saved so they survive a restart. Nothing is saved when it
+	// is empty.
+	StateDir string
+	// HealthInterval is how often storage nodes are health checked. Health
+	// checking is off when it is zero.
+	HealthInterval time.Duration
+	// EjectAfter is how long a node may stay down before it is taken off the
+	// ring and its files re-replicated. Down nodes are never ejected when it
+	// is zero.
+	EjectAfter time.Duration
+	// AntiEntropyInterval is how often replicas are compared and repaired.
+	// Anti-entropy is off when it is zero.
+	AntiEntropyInterval time.Duration
+	// RepairInterval is how often files quarantined by storage node
+	// scrubbers are restored from healthy replicas. Repair is off when it is
+	// zero.
+	RepairInterval time.Duration
+	// MigrationWorkers is how many files a migration moves at once.
+	MigrationWorkers int
+	// MigrationBandwidth caps migration traffic in bytes per second. There
+	// is no cap when it is zero.
+	MigrationBandwidth int64
+	// Placement picks each file's replicas from the nodes on the ring. It
+	// is consistent hashing on the ring when nil. If the saved ring was
+	// placed by another strategy, every file is rebalanced onto this one.
+	Placement PlacementStrategy
+	// LoadInterval is how often bounded-load placement measures the load
+	// of every ring range. Loads are never measured when it is zero.
+	LoadInterval time.Duration
 }
 
 // NetworkVideoContentService implements VideoContentService using a network of nodes.
 type NetworkVideoContentService struct {
-	hashRing []uint64
-	hashMap  map[uint64]*Node
-	mu       sync.RWMutex
+	ring     *hashRing
+	draining map[string]*Node
+	// prevRings are the rings since the cluster last settled, oldest
+	// first: every ring from before a migration in flight, or one that
+	// failed, began. They are dropped once a rebalance has finished without
+	// errors and no migration is running. transitions counts those
+	// migrations.
+	prevRings         []*hashRing
+	transitions       int
+	ringVersion       int64
+	replicationFactor int
+	virtualNodes      int
+	stateDir          string
+	mu                sync.RWMutex
+
+	journal     *migrationJournal
+	jobs        map[string]*migrationJob
+	jobsMu      sync.Mutex
+	migrationMu sync.Mutex
+	throttle    *migrationThrottle
+	damaged     map[string]*damagedFile
+	damagedMu   sync.Mutex
 	proto.UnimplementedVideoContentAdminServiceServer
 }
 
 type Node struct {
-	address string
-	client  proto.StorageServiceClient
+	address      string
+	conn         *grpc.ClientConn
+	client       proto.StorageServiceClient
+	virtualNodes int
+	weight       float64
+	labels       nodeLabels
+
+	healthMu  sync.Mutex
+	health    string
+	failures  int
+	downSince time.Time
 }
 
 // Uncomment the following line to ensure NetworkVideoContentService implements VideoContentService
 var _ VideoContentService = (*NetworkVideoContentService)(nil)
 
+func newNode(addr string, virtualNodes int, weight float64, labels nodeLabels) (*Node, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		address:      addr,
+		conn:         conn,
+		client:       proto.NewStorageServiceClient(conn),
+		virtualNodes: virtualNodes,
+		weight:       weight,
+		labels:       labels,
+		health:       healthUp,
+	}, nil
+}
+
+// maxNodeTokens caps the number of ring tokens one node can own, so a huge
+// virtual node count or weight cannot exhaust memory.
+const maxNodeTokens = 100000
+
+// nodeSettings applies the defaults for an added node's virtual node count
+// and weight, where 0 means the default, and checks them.
+func (n *NetworkVideoContentService) nodeSettings(virtualNodes int32, weight float64) (int, float64, error) {
+	if virtualNodes == 0 {
+		virtualNodes = int32(n.virtualNodes)
+	}
+	if weight == 0 {
+		weight = 1
+	}
+	if virtualNodes < 0 || weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0, 0, status.Error(codes.InvalidArgument, "virtual nodes and weight must be positive numbers, or 0 for the default")
+	}
+	if float64(virtualNodes)*weight > maxNodeTokens {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "virtual nodes times weight must be at most %d", maxNodeTokens)
+	}
+	return int(virtualNodes), weight, nil
+}
+
 func (n *NetworkVideoContentService) AddNode(ctx context.Context, req *proto.AddNodeRequest) (*proto.AddNodeResponse, error) {
 	addr := req.NodeAddress
-	hash := hashStringToUint64(addr)
+	virtualNodes, weight, err := n.nodeSettings(req.VirtualNodes, req.Weight)
+	if err != nil {
+		return nil, err
+	}
 	n.mu.Lock()
 	defer n.mu.Unlock()
-	idx := sort.Search(len(n.hashRing), func(i int) bool {
-		return n.hashRing[i] >= hash
-	})
-	if idx < len(n.hashRing) && n.hashRing[idx] == hash {
+	if n.ring.lookup(addr) != nil {
 		return &proto.AddNodeResponse{MigratedFileCount: 0}, nil
 	}
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if _, ok := n.draining[addr]; ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is draining; remove it before adding it again", addr)
+	}
+	node, err := newNode(addr, virtualNodes, weight, labelsFromProto(req.Labels))
 	if err != nil {
 		return nil, err
 	}
-	c := proto.NewStorageServiceClient(conn)
-	newNode := &Node{
-		address: addr,
-		client:  c,
-	}
-	n.hashRing = append(n.hashRing, hash)
-	n.hashMap[hash] = newNode
-	slices.Sort(n.hashRing)
+	n.beginTransitionLocked()
+	n.ring.add(node)
 
-	movedFiles := 0
-
-	for _, h := range n.hashRing {
-		if h == hash {
-			continue
-		}
-		node := n.hashMap[h]
-		resp, err := node.client.ListFiles(context.Background(), &proto.Empty{})
-		if err != nil {
-			return nil, err
-		}
-		for _, filename := range resp.Filenames {
-			successor := n.FindSuccessor(filename)
-			if successor == nil || successor.address != addr {
-				continue
-			}
-			paths := strings.Split(filename, "/")
-			videoId := paths[0]
-			file_chunk := paths[1]
-			resp, err := node.client.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: videoId, Filename: file_chunk})
-			if err != nil {
-				return nil, err
-			}
-			_, err = successor.client.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: videoId, Filename: file_chunk, Data: resp.Data})
-			if err != nil {
-				return nil, err
-			}
-			_, err = node.client.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: videoId, Filename: file_chunk})
-			if err != nil {
-				return nil, err
-			}
-			movedFiles += 1
-		}
-	}
-	return &proto.AddNodeResponse{MigratedFileCount: int32(movedFiles)}, nil
+	job := n.startJob(opAdd, node)
+	return &proto.AddNodeResponse{MigratedFileCount: 0, JobId: job.ID}, nil
 }
 
 func (n *NetworkVideoContentService) RemoveNode(ctx context.Context, req *proto.RemoveNodeRequest) (*proto.RemoveNodeResponse, error) {
 	n.mu.Lock()
-	addr := req.NodeAddress
-	hash := hashStringToUint64(addr)
 	defer n.mu.Unlock()
-	idx := sort.Search(len(n.hashRing), func(i int) bool {
-		return n.hashRing[i] >= hash
-	})
-	if idx == len(n.hashRing) {
+	if node, ok := n.draining[req.NodeAddress]; ok {
+		if err := n.removeDrainedLocked(ctx, node); err != nil {
+			return nil, err
+		}
 		return &proto.RemoveNodeResponse{MigratedFileCount: 0}, nil
 	}
-	nodeToDelete := n.hashMap[hash]
-	n.hashRing = slices.Delete(n.hashRing, idx, idx+1)
-	delete(n.hashMap, hash)
-
-	movedFiles := 0
-
-	resp, err := nodeToDelete.client.ListFiles(context.Background(), &proto.Empty{})
-	if err != nil {
+	nodeToDelete := n.ring.lookup(req.NodeAddress)
+	if nodeToDelete == nil {
+		return &proto.RemoveNodeResponse{MigratedFileCount: 0}, nil
+	}
+	if err := checkNotLast(n.ring, nodeToDelete); err != nil {
 		return nil, err
 	}
+	n.beginTransitionLocked()
+	n.ring.remove(nodeToDelete)
 
-	for _, filename := range resp.Filenames {
-		paths := strings.Split(filename, "/")
-		videoId := paths[0]
-		file_chunk := paths[1]
-		successor := n.FindSuccessor(filename)
-		if successor == nil {
-			continue
-		}
-		resp, err := nodeToDelete.client.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: videoId, Filename: file_chunk})
-		if err != nil {
-			return nil, err
-		}
-		_, err = successor.client.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: videoId, Filename: file_chunk, Data: resp.Data})
-		if err != nil {
-			return nil, err
-		}
-		_, err = nodeToDelete.client.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: videoId, Filename: file_chunk})
-		if err != nil {
-			return nil, err
-		}
-		movedFiles += 1
+	job := n.startJob(opRemove, nodeToDelete)
+	return &proto.RemoveNodeResponse{MigratedFileCount: 0, JobId: job.ID}, nil
+}
+
+// checkNotLast refuses to take node off r if no node would be left to hold
+// its files.
+func checkNotLast(r *hashRing, node *Node) error {
+	if len(r.nodes()) == 1 {
+		return status.Errorf(codes.FailedPrecondition, "node %s is the last node on the ring; add another before taking it off", node.address)
 	}
-	return &proto.RemoveNodeResponse{MigratedFileCount: int32(movedFiles)}, nil
+	return nil
 }
 
 func (n *NetworkVideoContentService) ListNodes(ctx context.Context, req *proto.ListNodesRequest) (*proto.ListNodesResponse, error) {
 	n.mu.RLock()
-	defer n.mu.RUnlock()
-	nodesAddresses := make([]string, len(n.hashRing))
-	for idx, hashedNode := range n.hashRing {
-		nodesAddresses[idx] = n.hashMap[hashedNode].address
+	nodes := append(n.ring.nodes(), n.drainingNodesLocked()...)
+	nodesAddresses := make([]string, len(nodes))
+	nodeInfo := make([]*proto.NodeInfo, len(nodes))
+	for idx, node := range nodes {
+		health, _ := node.healthState()
+		nodesAddresses[idx] = node.address
+		nodeInfo[idx] = &proto.NodeInfo{
+			Address:      node.address,
+			State:        health,
+			VirtualNodes: int32(node.virtualNodes),
+			Weight:       node.weight,
+			Draining:     n.draining[node.address] == node,
+			Labels:       node.labels.proto(),
+		}
 	}
-	return &proto.ListNodesResponse{Nodes: nodesAddresses}, nil
+	ringVersion := n.ringVersion
+	n.mu.RUnlock()
+
+	// Capacity is fetched outside the lock so slow nodes don't hold up
+	// membership changes.
+	var wg sync.WaitGroup
+	for idx, node := range nodes {
+		if nodeInfo[idx].State == healthDown {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, err := node.client.GetNodeStats(ctx, &proto.Empty{})
+			if err != nil {
+				nodeInfo[idx].StatsError = err.Error()
+				return
+			}
+			nodeInfo[idx].FileCount = stats.FileCount
+			nodeInfo[idx].UsedBytes = stats.UsedBytes
+			nodeInfo[idx].TotalBytes = stats.TotalBytes
+			nodeInfo[idx].FreeBytes = stats.FreeBytes
+		}()
+	}
+	wg.Wait()
+	return &proto.ListNodesResponse{Nodes: nodesAddresses, RingVersion: ringVersion, NodeInfo: nodeInfo}, nil
 }
 
-func NewNetworkVideoContentService(adminAddr string, addresses []string) (*NetworkVideoContentService, error) {
+func NewNetworkVideoContentService(adminAddr string, addresses []string, config NetworkConfig) (*NetworkVideoContentService, error) {
+	if config.ReplicationFactor < 1 {
+		return nil, errors.New("replication factor must be at least 1")
+	}
+	if config.VirtualNodes < 1 || config.VirtualNodes > maxNodeTokens {
+		return nil, fmt.Errorf("virtual nodes must be between 1 and %d", maxNodeTokens)
+	}
+	if config.MigrationWorkers < 1 {
+		return nil, errors.New("migration workers must be at least 1")
+	}
+	if config.MigrationBandwidth < 0 {
+		return nil, errors.New("migration bandwidth must not be negative")
+	}
+	if config.Placement == nil {
+		config.Placement = ringPlacement{}
+	}
 	n := &NetworkVideoContentService{
-		hashRing: make([]uint64, 0),
-		hashMap:  make(map[uint64]*Node),
+		ring:              newHashRing(config.Placement),
+		draining:          make(map[string]*Node),
+		replicationFactor: config.ReplicationFactor,
+		virtualNodes:      config.VirtualNodes,
+		stateDir:          config.StateDir,
+		jobs:              make(map[string]*migrationJob),
+		throttle:          newMigrationThrottle(config.MigrationWorkers, config.MigrationBandwidth),
+		damaged:           make(map[string]*damagedFile),
+	}
+
+	state, err := loadRingState(n.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		if err := n.restoreRing(state, addresses); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, addr := range addresses {
+			node, err := newNode(addr, n.virtualNodes, 1, nodeLabels{})
+			if err != nil {
+				return nil, err
+			}
+			n.ring.add(node)
+		}
+		n.ringChangedLocked()
+	}
+	journal, pending, err := openJournal(n.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	n.journal = journal
+	// Resumed jobs wait for n.mu before they start moving files.
+	n.mu.Lock()
+	n.recoverJournal(pending)
+	err = n.resumeJobs()
+	if err == nil && state != nil && state.savedPlacement() != config.Placement.Name() {
+		log.Printf("Placement changed from %s to %s; rebalancing every file\n", state.savedPlacement(), config.Placement.Name())
+		n.startJob(opPlacement, nil)
+	} else if err == nil && n.prevRings != nil && n.transitions == 0 {
+		// A crash or a failed migration left files that may still sit on
+		// the previous rings' nodes, with no job to move them.
+		log.Printf("Migrations left unfinished; rebalancing every file\n")
+		n.startJob(opPlacement, nil)
+	}
+	n.mu.Unlock()
+	if err != nil {
+		return nil, err
 	}
 
 	l, err := net.Listen("tcp", adminAddr)
@@ -172,63 +323,212 @@ func NewNetworkVideoContentService(adminAddr string, addresses []string) (*Netwo
 	proto.RegisterVideoContentAdminServiceServer(grpcServer, n)
 
 	go grpcServer.Serve(l)
-
-	for _, addr := range addresses {
-		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			return nil, err
-		}
-		node := &Node{address: addr, client: proto.NewStorageServiceClient(conn)}
-		hash := hashStringToUint64(addr)
-		n.hashRing = append(n.hashRing, hash)
-		n.hashMap[hash] = node
+	if config.HealthInterval > 0 {
+		go n.monitorHealth(config.HealthInterval, config.EjectAfter)
+	}
+	if config.AntiEntropyInterval > 0 {
+		go n.runAntiEntropy(config.AntiEntropyInterval)
+	}
+	if config.RepairInterval > 0 {
+		go n.runQuarantineRepair(config.RepairInterval)
+	}
+	if config.LoadInterval > 0 {
+		go n.runLoadBalancer(config.LoadInterval)
 	}
-	slices.Sort(n.hashRing)
 	return n, nil
 }
 
 func (n *NetworkVideoContentService) FindSuccessor(key string) *Node {
-	hash := hashStringToUint64(key)
-	if len(n.hashRing) == 0 {
+	successors := n.FindSuccessors(key, 1)
+	if len(successors) == 0 {
 		return nil
 	}
-	idx := sort.Search(len(n.hashRing), func(i int) bool {
-		return n.hashRing[i] >= hash
-	})
-	if idx == len(n.hashRing) {
-		idx = 0
-	}
-	return n.hashMap[n.hashRing[idx]]
+	return successors[0]
+}
+
+// FindSuccessors returns up to count distinct nodes responsible for key, as
+// chosen by the placement strategy.
+func (n *NetworkVideoContentService) FindSuccessors(key string, count int) []*Node {
+	return n.ring.keyReplicas(key, count)
 }
 
 func (n *NetworkVideoContentService) Read(videoId string, filename string) ([]byte, error) {
-	key := videoId + "/" + filename
-	node := n.FindSuccessor(key)
-	if node == nil {
+	if err := safepath.Check(videoId, filename); err != nil {
+		return nil, err
+	}
+	key := keyspace.Key(videoId, filename)
+	n.mu.RLock()
+	replicas := n.readCandidatesLocked(key)
+	n.mu.RUnlock()
+	if len(replicas) == 0 {
 		return nil, errors.New("couldn't find node")
 	}
-	req := &proto.ReadFileRequest{
-		VideoId:  videoId,
-		Filename: filename,
+	var err error
+	var corrupt []*Node
+	for _, node := range replicas {
+		var data []byte
+		data, err = readFile(context.Background(), node.client, videoId, filename)
+		if err == nil {
+			if len(corrupt) > 0 {
+				go n.repairReplicas(corrupt, videoId, filename, data)
+			}
+			return data, nil
+		}
+		if status.Code(err) == codes.DataLoss {
+			log.Printf("Corrupt copy of %s on %s: %v\n", key, node.address, err)
+			corrupt = append(corrupt, node)
+		}
 	}
-	resp, err := node.client.ReadFile(context.Background(), req)
-	if err != nil {
+	return nil, err
+}
+
+func (n *NetworkVideoContentService) ReadRange(videoId string, filename string, offset int64, length int64) ([]byte, *ContentInfo, error) {
+	if err := safepath.Check(videoId, filename); err != nil {
+		return nil, nil, err
+	}
+	key := keyspace.Key(videoId, filename)
+	n.mu.RLock()
+	replicas := n.readCandidatesLocked(key)
+	n.mu.RUnlock()
+	if len(replicas) == 0 {
+		return nil, nil, errors.New("couldn't find node")
+	}
+	var err error
+	for _, node := range replicas {
+		var data []byte
+		var header *proto.ReadFileResponse
+		data, header, err = readRange(context.Background(), node.client, videoId, filename, offset, length)
+		if err == nil {
+			return data, &ContentInfo{
+				Size:    header.Size,
+				ModTime: time.Unix(header.ModifiedAt, 0),
+				ETag:    `"` + hex.EncodeToString(header.Checksum) + `"`,
+			}, nil
+		}
+	}
+	if status.Code(err) == codes.NotFound {
+		return nil, nil, fmt.Errorf("%s: %w", key, os.ErrNotExist)
+	}
+	return nil, nil, err
+}
+
+func (n *NetworkVideoContentService) Stat(videoId string, filename string) (*ContentInfo, error) {
+	if err := safepath.Check(videoId, filename); err != nil {
 		return nil, err
 	}
-	return resp.Data, nil
+	key := keyspace.Key(videoId, filename)
+	n.mu.RLock()
+	replicas := n.readCandidatesLocked(key)
+	n.mu.RUnlock()
+	if len(replicas) == 0 {
+		return nil, errors.New("couldn't find node")
+	}
+	var err error
+	for _, node := range replicas {
+		var resp *proto.StatFileResponse
+		resp, err = node.client.StatFile(context.Background(), &proto.StatFileRequest{VideoId: videoId, Filename: filename})
+		if err == nil {
+			return &ContentInfo{
+				Size:    resp.Size,
+				ModTime: time.Unix(resp.ModifiedAt, 0),
+				ETag:    `"` + hex.EncodeToString(resp.Checksum) + `"`,
+			}, nil
+		}
+	}
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("%s: %w", key, os.ErrNotExist)
+	}
+	return nil, err
+}
+
+// repairReplicas overwrites corrupt copies of a file with data read from a
+// healthy replica.
+func (n *NetworkVideoContentService) repairReplicas(nodes []*Node, videoId string, filename string, data []byte) {
+	for _, node := range nodes {
+		if err := writeFile(context.Background(), node.client, videoId, filename, data, false); err != nil {
+			log.Printf("Failed to repair %s/%s on %s: %v\n", videoId, filename, node.address, err)
+			continue
+		}
+		log.Printf("Repaired %s/%s on %s\n", videoId, filename, node.address)
+	}
+}
+
+// readCandidatesLocked returns the nodes to try when reading key, in order:
+// its replicas on the current ring, healthiest first; while migrations are in
+// flight, its replicas on each previous ring, newest first, which may not have
+// handed the file over yet; and finally any draining nodes. The caller must
+// hold n.mu.
+func (n *NetworkVideoContentService) readCandidatesLocked(key string) []*Node {
+	candidates := n.FindSuccessors(key, n.replicationFactor)
+	slices.SortStableFunc(candidates, func(a, b *Node) int {
+		return healthRank(a) - healthRank(b)
+	})
+	var fallback []*Node
+	for _, r := range slices.Backward(n.prevRings) {
+		fallback = append(fallback, r.keyReplicas(key, n.replicationFactor)...)
+	}
+	fallback = append(fallback, n.drainingNodesLocked()...)
+	for _, node := range fallback {
+		if !slices.Contains(candidates, node) {
+			candidates = append(candidates, node)
+		}
+	}
+	return candidates
+}
+
+// healthRank orders nodes for reads: up, then suspect, then down.
+func healthRank(node *Node) int {
+	switch health, _ := node.healthState(); health {
+	case healthUp:
+		return 0
+	case healthSuspect:
+		return 1
+	default:
+		return 2
+	}
 }
 
 func (n *NetworkVideoContentService) Write(videoId string, filename string, data []byte) error {
-	key := videoId + "/" + filename
-	node := n.FindSuccessor(key)
-	if node == nil {
+	return n.write(videoId, filename, data, false)
+}
+
+func (n *NetworkVideoContentService) Create(videoId string, filename string, data []byte) error {
+	return n.write(videoId, filename, data, true)
+}
+
+func (n *NetworkVideoContentService) write(videoId string, filename string, data []byte, createOnly bool) error {
+	if err := safepath.Check(videoId, filename); err != nil {
+		return err
+	}
+	key := keyspace.Key(videoId, filename)
+	n.mu.RLock()
+	replicas := n.FindSuccessors(key, n.replicationFactor)
+	n.mu.RUnlock()
+	if len(replicas) == 0 {
 		return errors.New("couldn't find node")
 	}
-	req := &proto.WriteFileRequest{
-		VideoId:  videoId,
-		Filename: filename,
-		Data:     data,
+	written := 0
+	// Writes go to the current ring, which is where the file must live once
+	// the migrations in flight finish. Replicas are always written in the
+	// same order, so of two concurrent creates the one that loses on the
+	// first live replica stops there.
+	for _, node := range replicas {
+		// Down nodes are skipped; their copy is restored once they are
+		// ejected or come back and the cluster is rebalanced.
+		if health, _ := node.healthState(); health == healthDown {
+			continue
+		}
+		err := writeFile(context.Background(), node.client, videoId, filename, data, createOnly)
+		if status.Code(err) == codes.AlreadyExists {
+			return fmt.Errorf("%s on %s: %w", key, node.address, os.ErrExist)
+		}
+		if err != nil {
+			return err
+		}
+		written += 1
+	}
+	if written == 0 {
+		return errors.New("all replicas are down")
 	}
-	_, err := node.client.WriteFile(context.Background(), req)
-	return err
+	return nil
 }
@@ -0,0 +1,233 @@
+package web
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"tritontube/internal/keyspace"
+)
+
+// PlacementStrategy decides which nodes on the ring hold each key. Every
+// strategy is deterministic, so any web server with the same membership
+// finds a key's replicas in the same place.
+type PlacementStrategy interface {
+	// Name is the name the strategy is selected by.
+	Name() string
+	// replicas returns up to count distinct nodes from r to hold the key
+	// with the given hash, most preferred first.
+	replicas(r *hashRing, hash uint64, count int) []*Node
+	// rangeBased reports whether every key in the ring range between two
+	// adjacent tokens has the same replicas, so ranges can be listed and
+	// compared as a whole.
+	rangeBased() bool
+}
+
+// ParsePlacement returns the placement strategy with the given name: "ring"
+// for consistent hashing on the token ring, "rendezvous" for highest random
+// weight hashing, "jump" for jump consistent hashing, or
+// "bounded[:factor[:files|bytes]]" for consistent hashing with loads bounded
+// to factor times the average, by file count unless bytes is given.
+//
+// Any of these may be followed by "+video" to place files by video ID, so a
+// video's files share one replica set, or "+video:stripes" to spread each
+// video over that many replica sets.
+func ParsePlacement(name string) (PlacementStrategy, error) {
+	if base, video, ok := strings.Cut(name, "+"); ok {
+		return parseVideo(base, video)
+	}
+	if options, ok := strings.CutPrefix(name, "bounded"); ok && (options == "" || options[0] == ':') {
+		return parseBounded(strings.TrimPrefix(options, ":"))
+	}
+	switch name {
+	case "ring":
+		return ringPlacement{}, nil
+	case "rendezvous":
+		return rendezvousPlacement{}, nil
+	case "jump":
+		return jumpPlacement{}, nil
+	}
+	return nil, fmt.Errorf("unknown placement strategy %q", name)
+}
+
+// ringPlacement gives a key to the first nodes clockwise from its hash.
+type ringPlacement struct{}
+
+func (ringPlacement) Name() string { return "ring" }
+
+func (ringPlacement) replicas(r *hashRing, hash uint64, count int) []*Node {
+	return r.pick(count, func(n int) []*Node {
+		return r.successors(hash, n)
+	})
+}
+
+func (ringPlacement) rangeBased() bool { return true }
+
+// rendezvousPlacement gives a key to the nodes that score highest for it.
+// Scores are weighted so each node's share of keys is proportional to its
+// weight; virtual nodes play no part. Adding or removing a node only moves
+// keys to or from that node.
+type rendezvousPlacement struct{}
+
+func (rendezvousPlacement) Name() string { return "rendezvous" }
+
+func (rendezvousPlacement) replicas(r *hashRing, hash uint64, count int) []*Node {
+	nodes := r.nodes()
+	scores := make(map[*Node]float64, len(nodes))
+	for _, node := range nodes {
+		// Map the pair to a uniform value in (0, 1); -weight/ln(u) is
+		// largest for a node with probability proportional to its weight.
+		u := (float64(mix64(hash^keyspace.Hash(node.address))>>11) + 0.5) / (1 << 53)
+		scores[node] = -node.weight / math.Log(u)
+	}
+	slices.SortStableFunc(nodes, func(a, b *Node) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+	return r.pick(count, func(n int) []*Node {
+		return nodes[:min(n, len(nodes))]
+	})
+}
+
+func (rendezvousPlacement) rangeBased() bool { return false }
+
+// jumpPlacement spreads keys over buckets with jump consistent hashing. Each
+// node owns as many consecutive buckets as it has ring tokens, in the order
+// nodes joined, so adding a node only moves keys onto it. Removing any node
+// but the last one renumbers the buckets after it and moves many keys.
+type jumpPlacement struct{}
+
+func (jumpPlacement) Name() string { return "jump" }
+
+func (jumpPlacement) replicas(r *hashRing, hash uint64, count int) []*Node {
+	return r.pick(count, func(n int) []*Node {
+		return jumpReplicas(r, hash, n)
+	})
+}
+
+func (jumpPlacement) rangeBased() bool { return false }
+
+// jumpReplicas returns up to count distinct nodes for hash, most preferred
+// first.
+func jumpReplicas(r *hashRing, hash uint64, count int) []*Node {
+	members := r.nodes()
+	var buckets []*Node
+	for _, node := range members {
+		for range tokenCount(node.virtualNodes, node.weight) {
+			buckets = append(buckets, node)
+		}
+	}
+	count = min(count, len(members))
+	replicas := make([]*Node, 0, count)
+	// Each replica is drawn with a new seed derived from the key, skipping
+	// nodes already chosen. Nodes with tiny weights may rarely be drawn, so
+	// after a bounded number of draws the rest are taken in join order.
+	seed := hash
+	for draw := 0; draw < 64*count && len(replicas) < count; draw++ {
+		node := buckets[jumpHash(seed, len(buckets))]
+		if !slices.Contains(replicas, node) {
+			replicas = append(replicas, node)
+		}
+		seed = mix64(seed)
+	}
+	for _, node := range members {
+		if len(replicas) == count {
+			break
+		}
+		if !slices.Contains(replicas, node) {
+			replicas = append(replicas, node)
+		}
+	}
+	return replicas
+}
+
+// jumpHash is Lamping and Veach's jump consistent hash, mapping key to one
+// of buckets buckets.
+func jumpHash(key uint64, buckets int) int {
+	b, j := int64(-1), int64(0)
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// mix64 scrambles x with the SplitMix64 finalizer.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// keyHasher is implemented by strategies that place a file by something
+// other than the hash of its full name.
+type keyHasher interface {
+	hashKey(key string) uint64
+}
+
+// videoPlacement places each file by its video ID instead of its full name,
+// using base to pick the replicas. With more than one stripe, a video's files
+// are spread over that many replica sets by the hash of their filename;
+// stripe 0 is placed like an unstriped video, so raising the stripe count
+// only moves the files that change stripe.
+//
+// Files sharing a replica set are scattered across storage nodes' hash
+// ranges, so video placement is never range based.
+type videoPlacement struct {
+	base    PlacementStrategy
+	stripes int
+}
+
+func parseVideo(base string, video string) (PlacementStrategy, error) {
+	strategy, err := ParsePlacement(base)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := strategy.(videoPlacement); ok {
+		return nil, errors.New("video placement cannot be nested")
+	}
+	p := videoPlacement{base: strategy, stripes: 1}
+	if stripes, ok := strings.CutPrefix(video, "video:"); ok {
+		if p.stripes, err = strconv.Atoi(stripes); err != nil || p.stripes < 1 {
+			return nil, fmt.Errorf("video stripe count %q must be a positive number", stripes)
+		}
+	} else if video != "video" {
+		return nil, fmt.Errorf("unknown placement mode %q", video)
+	}
+	return p, nil
+}
+
+func (p videoPlacement) Name() string {
+	if p.stripes == 1 {
+		return p.base.Name() + "+video"
+	}
+	return fmt.Sprintf("%s+video:%d", p.base.Name(), p.stripes)
+}
+
+func (p videoPlacement) replicas(r *hashRing, hash uint64, count int) []*Node {
+	return p.base.replicas(r, hash, count)
+}
+
+func (videoPlacement) rangeBased() bool { return false }
+
+func (p videoPlacement) hashKey(key string) uint64 {
+	videoId, filename := keyspace.Split(key)
+	stripe := keyspace.Hash(filename) % uint64(p.stripes)
+	if stripe == 0 {
+		return keyspace.Hash(videoId)
+	}
+	return keyspace.Hash(fmt.Sprintf("%s#%d", videoId, stripe))
+}
+
+// basePlacement returns the strategy that picks replicas for p, looking
+// through video placement.
+func basePlacement(p PlacementStrategy) PlacementStrategy {
+	if video, ok := p.(videoPlacement); ok {
+		return video.base
+	}
+	return p
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"tritontube/internal/keyspace"
+)
+
+// placementRing returns a ring placed by p with nodes added in order.
+func placementRing(p PlacementStrategy, nodes ...*Node) *hashRing {
+	r := newHashRing(p)
+	for _, node := range nodes {
+		r.add(node)
+	}
+	return r
+}
+
+// testNodes returns count nodes with 16 virtual nodes each.
+func testNodes(count int) []*Node {
+	nodes := make([]*Node, count)
+	for i := range nodes {
+		nodes[i] = &Node{address: fmt.Sprintf("node%d:9000", i), virtualNodes: 16, weight: 1}
+	}
+	return nodes
+}
+
+func TestParsePlacement(t *testing.T) {
+	tests := []struct {
+		name string
+		// want is the name of the strategy parsed, or "" if there is none.
+		want string
+	}{
+		{"ring", "ring"},
+		{"rendezvous", "rendezvous"},
+		{"jump", "jump"},
+		{"bounded", "bounded:1.25:files"},
+		{"", ""},
+		{"chord", ""},
+		{"Ring", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePlacement(tt.name)
+			if (err == nil) != (tt.want != "") {
+				t.Fatalf("ParsePlacement(%q) error %v", tt.name, err)
+			}
+			if err == nil && p.Name() != tt.want {
+				t.Errorf("ParsePlacement(%q) is named %q, want %q", tt.name, p.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPlacementDeterministic(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement PlacementStrategy
+		// anyOrder is set if the order nodes joined in does not matter.
+		anyOrder bool
+	}{
+		{"ring", ringPlacement{}, true},
+		{"rendezvous", rendezvousPlacement{}, true},
+		{"jump", jumpPlacement{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rings built separately from nodes with the same settings
+			// place every key alike.
+			a := placementRing(tt.placement, testNodes(5)...)
+			nodes := testNodes(5)
+			if tt.anyOrder {
+				slices.Reverse(nodes)
+			}
+			b := placementRing(tt.placement, nodes...)
+			for i := range 2000 {
+				key := fmt.Sprint(i)
+				for _, count := range []int{1, 3, 5, 7} {
+					want := nodeAddresses(a.keyReplicas(key, count))
+					got := nodeAddresses(b.keyReplicas(key, count))
+					if !slices.Equal(got, want) {
+						t.Fatalf("replicas of %s: %v, then %v", key, want, got)
+					}
+					if len(got) != min(count, 5) || len(slices.Compact(sortedNodes(b.keyReplicas(key, count)))) != len(got) {
+						t.Fatalf("replicas of %s are %v, want %d distinct nodes", key, got, min(count, 5))
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestPlacementMovement checks that a membership change only moves keys onto
+// an added node, or off a removed one.
+func TestPlacementMovement(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement PlacementStrategy
+		count     int
+		// remove removes the given node instead of adding a node.
+		remove int
+	}{
+		{"rendezvous add", rendezvousPlacement{}, 1, -1},
+		{"rendezvous add with replicas", rendezvousPlacement{}, 3, -1},
+		{"rendezvous remove first", rendezvousPlacement{}, 1, 0},
+		{"rendezvous remove with replicas", rendezvousPlacement{}, 3, 2},
+		{"jump add", jumpPlacement{}, 1, -1},
+		{"jump remove last", jumpPlacement{}, 1, 5},
+		{"ring add", ringPlacement{}, 1, -1},
+		{"ring add with replicas", ringPlacement{}, 3, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := testNodes(6)
+			before := placementRing(tt.placement, nodes[:5]...)
+			after := placementRing(tt.placement, nodes...)
+			changed := nodes[5]
+			if tt.remove >= 0 {
+				changed = nodes[tt.remove]
+				before = after
+				after = placementRing(tt.placement, slices.DeleteFunc(slices.Clone(nodes), func(node *Node) bool {
+					return node == changed
+				})...)
+			}
+			const keys = 5000
+			moved := 0
+			for i := range keys {
+				key := fmt.Sprint(i)
+				old, cur := before.keyReplicas(key, tt.count), after.keyReplicas(key, tt.count)
+				gained := slices.DeleteFunc(slices.Clone(cur), func(node *Node) bool {
+					return slices.Contains(old, node)
+				})
+				lost := slices.DeleteFunc(slices.Clone(old), func(node *Node) bool {
+					return slices.Contains(cur, node)
+				})
+				if tt.remove < 0 && slices.ContainsFunc(gained, func(node *Node) bool { return node != changed }) {
+					t.Fatalf("key %s moved from %v to %v, not only onto the added node", key, nodeAddresses(old), nodeAddresses(cur))
+				}
+				if tt.remove >= 0 && slices.ContainsFunc(lost, func(node *Node) bool { return node != changed }) {
+					t.Fatalf("key %s moved from %v to %v, not only off the removed node", key, nodeAddresses(old), nodeAddresses(cur))
+				}
+				if len(gained) > 0 {
+					moved += 1
+				}
+			}
+			// The changed node is one of six, so it holds about count/6
+			// of the keys.
+			share := float64(moved) / keys
+			if want := float64(tt.count) / 6; share < want/2 || share > want*1.5 {
+				t.Errorf("%.3f of keys moved, want about %.3f", share, want)
+			}
+		})
+	}
+}
+
+// TestPlacementWeights checks that weighted strategies give each node a share
+// of keys proportional to its weight.
+func TestPlacementWeights(t *testing.T) {
+	for _, p := range []PlacementStrategy{rendezvousPlacement{}, jumpPlacement{}} {
+		t.Run(p.Name(), func(t *testing.T) {
+			light := &Node{address: "light:9000", virtualNodes: 16, weight: 1}
+			heavy := &Node{address: "heavy:9000", virtualNodes: 16, weight: 3}
+			r := placementRing(p, light, heavy)
+			held := 0
+			const keys = 8000
+			for i := range keys {
+				if r.replicas(keyspace.Hash(fmt.Sprint(i)), 1)[0] == heavy {
+					held += 1
+				}
+			}
+			if share := float64(held) / keys; share < 0.7 || share > 0.8 {
+				t.Errorf("weight 3 node holds %.3f of keys next to a weight 1 node, want about 0.75", share)
+			}
+		})
+	}
+}
+
+func TestParseVideoPlacement(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ring+video", "ring+video"},
+		{"jump+video", "jump+video"},
+		{"rendezvous+video:4", "rendezvous+video:4"},
+		{"ring+video:1", "ring+video"},
+		{"ring+video:0", ""},
+		{"ring+video:-2", ""},
+		{"ring+video:x", ""},
+		{"ring+videos", ""},
+		{"chord+video", ""},
+		{"ring+video+video", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePlacement(tt.name)
+			if (err == nil) != (tt.want != "") {
+				t.Fatalf("ParsePlacement(%q) error %v", tt.name, err)
+			}
+			if err == nil && p.Name() != tt.want {
+				t.Errorf("ParsePlacement(%q) is named %q, want %q", tt.name, p.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestVideoPlacementHashKey(t *testing.T) {
+	filenames := make([]string, 200)
+	for i := range filenames {
+		filenames[i] = fmt.Sprintf("segment_%d.m4s", i)
+	}
+	for _, stripes := range []int{1, 2, 4, 7} {
+		t.Run(fmt.Sprint(stripes), func(t *testing.T) {
+			p := videoPlacement{base: ringPlacement{}, stripes: stripes}
+			unstriped := videoPlacement{base: ringPlacement{}, stripes: 1}
+			hashes := make(map[uint64]bool)
+			for _, filename := range filenames {
+				key := keyspace.Key("video", filename)
+				h := p.hashKey(key)
+				hashes[h] = true
+				// Stripe 0 is placed like an unstriped video, so only
+				// the files in other stripes move when striping.
+				stripe0 := keyspace.Hash(filename)%uint64(stripes) == 0
+				if (h == unstriped.hashKey(key)) != stripe0 {
+					t.Errorf("%s in stripe 0 %v, but placed like an unstriped video %v", filename, stripe0, !stripe0)
+				}
+				if h == p.hashKey(keyspace.Key("other", filename)) {
+					t.Errorf("%s placed alike in two videos", filename)
+				}
+			}
+			if len(hashes) != stripes {
+				t.Errorf("files placed by %d hashes, want one per stripe", len(hashes))
+			}
+		})
+	}
+}
+
+// TestVideoPlacementReplicas checks that a video's files share replica sets
+// while the ring places them, through its keyHasher.
+func TestVideoPlacementReplicas(t *testing.T) {
+	for _, base := range []PlacementStrategy{ringPlacement{}, rendezvousPlacement{}, jumpPlacement{}} {
+		t.Run(base.Name(), func(t *testing.T) {
+			r := placementRing(videoPlacement{base: base, stripes: 1}, testNodes(6)...)
+			want := nodeAddresses(r.keyReplicas(keyspace.Key("video", "manifest.mpd"), 2))
+			for i := range 50 {
+				key := keyspace.Key("video", fmt.Sprintf("segment_%d.m4s", i))
+				if got := nodeAddresses(r.keyReplicas(key, 2)); !slices.Equal(got, want) {
+					t.Fatalf("%s is on %v, but the manifest is on %v", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PlanMembershipChange reports which files adding or removing a node would
+// move, using the same placement as AddNode and RemoveNode, without changing
+// the ring or any stored data.
+func (n *NetworkVideoContentService) PlanMembershipChange(ctx context.Context, req *proto.PlanMembershipChangeRequest) (*proto.PlanMembershipChangeResponse, error) {
+	n.mu.RLock()
+	r := n.ring.clone()
+	n.mu.RUnlock()
+
+	walk := walkAll(ctx, r.nodes(), nil)
+	switch req.Operation {
+	case opAdd:
+		if r.lookup(req.NodeAddress) != nil {
+			return &proto.PlanMembershipChangeResponse{}, nil
+		}
+		virtualNodes, weight, err := n.nodeSettings(req.VirtualNodes, req.Weight)
+		if err != nil {
+			return nil, err
+		}
+		node, err := newNode(req.NodeAddress, virtualNodes, weight, labelsFromProto(req.Labels))
+		if err != nil {
+			return nil, err
+		}
+		defer node.conn.Close()
+		// The new node may already hold some files, which then need not
+		// be copied to it. One that cannot be reached yet is taken to be
+		// empty.
+		reachable := checkNode(node, probeTimeout)
+		sources := r.nodes()
+		if reachable {
+			sources = append(sources, node)
+		}
+		walk = walkAll(ctx, sources, nil)
+		r.add(node)
+		if canTakeOver(r) {
+			ranges, holders := n.takeoverRanges(r, node)
+			if reachable {
+				holders = append(holders, node)
+			}
+			walk = walkRanges(ctx, holders, ranges, nil)
+		}
+	case opRemove:
+		node := r.lookup(req.NodeAddress)
+		if node == nil {
+			return &proto.PlanMembershipChangeResponse{}, nil
+		}
+		// RemoveNode refuses this, so the plan does too rather than
+		// reporting that nothing would move.
+		if err := checkNotLast(r, node); err != nil {
+			return nil, err
+		}
+		r.remove(node)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown operation %q", req.Operation)
+	}
+
+	resp := &proto.PlanMembershipChangeResponse{}
+	transfers := make(map[[2]string]*proto.PlannedTransfer)
+	err := walk(func(key string, file *heldFile) error {
+		m, ok := n.planMove(key, file, r)
+		if !ok {
+			return nil
+		}
+		// Files that only lose an extra copy are not counted as moving.
+		copied := false
+		for _, successor := range m.want {
+			if slices.Contains(m.have, successor) {
+				continue
+			}
+			copied = true
+			// rebalance copies from the first holder.
+			source := m.have[0].address
+			pair := [2]string{source, successor.address}
+			t, ok := transfers[pair]
+			if !ok {
+				t = &proto.PlannedTransfer{Source: source, Destination: successor.address}
+				transfers[pair] = t
+				resp.Transfers = append(resp.Transfers, t)
+			}
+			t.FileCount += 1
+			t.Bytes += m.size
+			resp.TotalBytes += m.size
+			if req.IncludeFiles {
+				resp.Files = append(resp.Files, &proto.PlannedFile{
+					Filename:    m.key,
+					Source:      source,
+					Destination: successor.address,
+					Bytes:       m.size,
+				})
+			}
+		}
+		if copied {
+			resp.TotalFiles += 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(resp.Transfers, func(a, b *proto.PlannedTransfer) int {
+		if c := strings.Compare(a.Source, b.Source); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Destination, b.Destination)
+	})
+	return resp, nil
+}
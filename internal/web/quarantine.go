@@ -0,0 +1,134 @@
+package web
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// damagedFile is a file that was quarantined on some nodes and could not be
+// restored because no replica held a good copy.
+type damagedFile struct {
+	nodes      []string
+	detectedAt time.Time
+}
+
+// runQuarantineRepair restores the files storage nodes quarantined every
+// interval.
+func (n *NetworkVideoContentService) runQuarantineRepair(interval time.Duration) {
+	for range time.Tick(interval) {
+		repaired := n.repairQuarantined()
+		if repaired > 0 {
+			log.Printf("Quarantine: restored %d file copies\n", repaired)
+		}
+	}
+}
+
+// repairQuarantined asks every live node for the files its scrubber
+// quarantined. Each one a node should hold is rewritten from a replica whose
+// copy passes its checksum; one it should not hold is just cleared. Files
+// with no good copy anywhere are flagged as damaged. Passes are skipped while
+// a migration runs. It returns the number of copies restored.
+func (n *NetworkVideoContentService) repairQuarantined() int {
+	if !n.migrationMu.TryLock() {
+		return 0
+	}
+	defer n.migrationMu.Unlock()
+	n.mu.RLock()
+	nodes := append(n.ring.nodes(), n.drainingNodesLocked()...)
+	n.mu.RUnlock()
+
+	repaired := 0
+	for _, node := range nodes {
+		if health, _ := node.healthState(); health == healthDown {
+			continue
+		}
+		resp, err := node.client.ListQuarantined(context.Background(), &proto.Empty{})
+		if err != nil {
+			if status.Code(err) != codes.Unimplemented {
+				log.Printf("Quarantine: list from %s: %v\n", node.address, err)
+			}
+			continue
+		}
+		for _, file := range resp.Files {
+			if n.restoreQuarantined(node, file) {
+				repaired += 1
+			}
+		}
+	}
+	return repaired
+}
+
+// restoreQuarantined handles one quarantined file on node and reports
+// whether a good copy was written back to it.
+func (n *NetworkVideoContentService) restoreQuarantined(node *Node, file *proto.QuarantinedFile) bool {
+	videoId, filename := keyspace.Split(file.Name)
+	n.mu.RLock()
+	owners := n.FindSuccessors(file.Name, n.replicationFactor)
+	candidates := n.readCandidatesLocked(file.Name)
+	n.mu.RUnlock()
+
+	if !slices.Contains(owners, node) {
+		// The node is not meant to hold the file; dropping it clears the
+		// quarantine.
+		_, err := node.client.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: videoId, Filename: filename})
+		if err != nil && status.Code(err) != codes.NotFound {
+			log.Printf("Quarantine: clear %s on %s: %v\n", file.Name, node.address, err)
+		}
+		return false
+	}
+	for _, source := range candidates {
+		if source == node {
+			continue
+		}
+		data, err := readFile(context.Background(), source.client, videoId, filename)
+		if err != nil {
+			continue
+		}
+		if err := writeFile(context.Background(), node.client, videoId, filename, data, false); err != nil {
+			log.Printf("Quarantine: restore %s on %s: %v\n", file.Name, node.address, err)
+			return false
+		}
+		log.Printf("Quarantine: restored %s on %s from %s\n", file.Name, node.address, source.address)
+		n.damagedMu.Lock()
+		delete(n.damaged, file.Name)
+		n.damagedMu.Unlock()
+		return true
+	}
+	n.damagedMu.Lock()
+	defer n.damagedMu.Unlock()
+	damaged, ok := n.damaged[file.Name]
+	if !ok {
+		log.Printf("Quarantine: no good copy of %s left, flagging it as damaged\n", file.Name)
+		damaged = &damagedFile{detectedAt: time.Unix(file.QuarantinedAt, 0)}
+		n.damaged[file.Name] = damaged
+	}
+	if !slices.Contains(damaged.nodes, node.address) {
+		damaged.nodes = append(damaged.nodes, node.address)
+	}
+	return false
+}
+
+func (n *NetworkVideoContentService) ListDamagedFiles(ctx context.Context, req *proto.ListDamagedFilesRequest) (*proto.ListDamagedFilesResponse, error) {
+	n.damagedMu.Lock()
+	defer n.damagedMu.Unlock()
+	resp := &proto.ListDamagedFilesResponse{}
+	for name, damaged := range n.damaged {
+		resp.Files = append(resp.Files, &proto.DamagedFile{
+			Filename:   name,
+			Nodes:      slices.Clone(damaged.nodes),
+			DetectedAt: damaged.detectedAt.Unix(),
+		})
+	}
+	slices.SortFunc(resp.Files, func(a, b *proto.DamagedFile) int {
+		return strings.Compare(a.Filename, b.Filename)
+	})
+	return resp, nil
+}
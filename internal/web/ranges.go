@@ -0,0 +1,111 @@
+package web
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+)
+
+// keyRange is the ring range (start, end], which wraps past zero when
+// end <= start and is the whole ring when they are equal.
+type keyRange struct {
+	start uint64
+	end   uint64
+}
+
+// compare orders keys the way ListFilesInRange lists them: by how far past
+// the range's start their hash is, and then by name.
+func (kr keyRange) compare(a string, b string) int {
+	if c := cmp.Compare(keyspace.Hash(a)-kr.start-1, keyspace.Hash(b)-kr.start-1); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// listRange lists the files on node in kr.
+func listRange(ctx context.Context, node *Node, kr keyRange) *fileLister {
+	return &fileLister{node: node, fetch: func(token string) (*proto.ListFilesResponse, error) {
+		return node.client.ListFilesInRange(ctx, &proto.ListFilesInRangeRequest{
+			Start:     kr.start,
+			End:       kr.end,
+			PageToken: token,
+			PageSize:  listPageSize,
+		})
+	}}
+}
+
+// walkRanges walks the files in ranges on nodes. skip is as for walkHolders.
+func walkRanges(ctx context.Context, nodes []*Node, ranges []keyRange, skip func(error)) holderWalk {
+	return func(fn func(key string, file *heldFile) error) error {
+		for _, kr := range ranges {
+			listers := make([]*fileLister, len(nodes))
+			for i, node := range nodes {
+				listers[i] = listRange(ctx, node, kr)
+			}
+			if err := walkHolders(listers, kr.compare, fn, skip); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// canTakeOver reports whether takeoverRanges applies to r. Only plain
+// consistent hashing, without replicas spread by labels, hands a joining node
+// exactly the ranges behind its tokens.
+func canTakeOver(r *hashRing) bool {
+	_, ok := r.placement.(ringPlacement)
+	return ok && !r.labeled()
+}
+
+// takeoverRanges returns the ring ranges in which node, on r, is one of a
+// key's replicas, and the nodes that held the replicas of those keys on r
+// without node. Those are the only keys whose replica set node's joining
+// changes. r must satisfy canTakeOver.
+func (n *NetworkVideoContentService) takeoverRanges(r *hashRing, node *Node) ([]keyRange, []*Node) {
+	old := r.clone()
+	old.remove(node)
+	var ranges []keyRange
+	var sources []*Node
+	addSources := func(hash uint64) {
+		for _, source := range old.replicas(hash, n.replicationFactor) {
+			if !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+	for i, token := range r.tokens {
+		if r.owners[token] != node {
+			continue
+		}
+		addSources(token)
+		// Walk back from the token: node is a replica of every key from
+		// which fewer than replicationFactor other nodes are passed before
+		// reaching it. Ranges in front of node's other tokens are covered
+		// when those tokens are visited.
+		// If the walk gets all the way round, the range is the whole ring.
+		start := token
+		var passed []*Node
+		for j := 1; j < len(r.tokens); j++ {
+			prev := r.tokens[(i-j+len(r.tokens))%len(r.tokens)]
+			owner := r.owners[prev]
+			if owner == node {
+				start = prev
+				break
+			}
+			if !slices.Contains(passed, owner) {
+				passed = append(passed, owner)
+			}
+			if len(passed) == n.replicationFactor {
+				start = prev
+				break
+			}
+			addSources(prev)
+		}
+		ranges = append(ranges, keyRange{start: start, end: token})
+	}
+	return ranges, sources
+}
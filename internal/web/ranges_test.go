@@ -0,0 +1,76 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"tritontube/internal/keyspace"
+)
+
+// testRing returns a ring of nodes that are never connected to.
+func testRing(virtualNodes int, addrs ...string) *hashRing {
+	r := newHashRing(ringPlacement{})
+	for _, addr := range addrs {
+		r.add(&Node{address: addr, virtualNodes: virtualNodes, weight: 1})
+	}
+	return r
+}
+
+func TestTakeoverRanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		virtualNodes int
+		members      int
+		replicas     int
+	}{
+		{"only node", 1, 1, 1},
+		{"second node", 1, 2, 1},
+		{"one token each", 1, 4, 1},
+		{"one token each with replicas", 1, 4, 2},
+		{"virtual nodes", 8, 4, 1},
+		{"virtual nodes with replicas", 8, 5, 3},
+		{"more replicas than nodes", 4, 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var addrs []string
+			for i := range tt.members {
+				addrs = append(addrs, fmt.Sprintf("node%d:9000", i))
+			}
+			r := testRing(tt.virtualNodes, addrs...)
+			node := r.lookup(addrs[len(addrs)-1])
+			old := testRing(tt.virtualNodes, addrs[:len(addrs)-1]...)
+			n := &NetworkVideoContentService{replicationFactor: tt.replicas}
+			ranges, sources := n.takeoverRanges(r, node)
+
+			// Sample keys everywhere, including exactly on and next to
+			// every token.
+			var hashes []uint64
+			for i := range 5000 {
+				hashes = append(hashes, keyspace.Hash(fmt.Sprint(i)))
+			}
+			for _, token := range r.tokens {
+				hashes = append(hashes, token-1, token, token+1)
+			}
+			for _, h := range hashes {
+				replica := slices.Contains(r.replicas(h, tt.replicas), node)
+				covered := slices.ContainsFunc(ranges, func(kr keyRange) bool {
+					return keyspace.InRange(h, kr.start, kr.end)
+				})
+				if replica != covered {
+					t.Fatalf("hash %d: node is replica %v, but in takeover ranges %v", h, replica, covered)
+				}
+				if !covered {
+					continue
+				}
+				for _, holder := range old.replicas(h, tt.replicas) {
+					if !slices.ContainsFunc(sources, func(source *Node) bool {
+						return source.address == holder.address
+					}) {
+						t.Fatalf("hash %d: previous holder %s is not a source", h, holder.address)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"tritontube/internal/keyspace"
+)
+
+// hashRing is a consistent hash ring on which every node owns one or more
+// virtual tokens. It also records the order nodes joined in, and the
+// placement strategy that picks each key's replicas from its nodes.
+type hashRing struct {
+	tokens    []uint64
+	owners    map[uint64]*Node
+	members   []*Node
+	placement PlacementStrategy
+	// loads is the measured load of the range ending at each token, used by
+	// bounded-load placement; nil if never measured.
+	loads map[uint64]rangeLoad
+
+	// cache holds placements computed from the ring by replica count. It is
+	// cleared whenever the ring changes.
+	cacheMu sync.Mutex
+	cache   map[int][][]*Node
+}
+
+func newHashRing(placement PlacementStrategy) *hashRing {
+	return &hashRing{
+		tokens:    make([]uint64, 0),
+		owners:    make(map[uint64]*Node),
+		members:   make([]*Node, 0),
+		placement: placement,
+	}
+}
+
+// clone returns a copy of the ring that can be changed without affecting r.
+func (r *hashRing) clone() *hashRing {
+	owners := make(map[uint64]*Node, len(r.owners))
+	for token, node := range r.owners {
+		owners[token] = node
+	}
+	return &hashRing{
+		tokens:    slices.Clone(r.tokens),
+		owners:    owners,
+		members:   slices.Clone(r.members),
+		placement: r.placement,
+		loads:     r.loads,
+	}
+}
+
+// setLoads replaces the measured range loads. loads must not be modified
+// afterwards, since clones share it.
+func (r *hashRing) setLoads(loads map[uint64]rangeLoad) {
+	r.loads = loads
+	r.changed()
+}
+
+// changed clears the cached placements after the ring changes.
+func (r *hashRing) changed() {
+	r.cacheMu.Lock()
+	r.cache = nil
+	r.cacheMu.Unlock()
+}
+
+// tokenCount is the number of virtual tokens a node with the given settings
+// owns: its virtual node count scaled by its weight, and at least one.
+func tokenCount(virtualNodes int, weight float64) int {
+	return max(1, int(math.Round(float64(virtualNodes)*weight)))
+}
+
+// nodeTokens returns the ring positions of a node's virtual tokens. The first
+// token is the hash of the bare address, so a node with a single token lands
+// where it did before virtual nodes existed.
+func nodeTokens(addr string, count int) []uint64 {
+	tokens := make([]uint64, count)
+	tokens[0] = keyspace.Hash(addr)
+	for i := 1; i < count; i++ {
+		tokens[i] = keyspace.Hash(fmt.Sprintf("%s#%d", addr, i))
+	}
+	return tokens
+}
+
+// add places all of node's tokens on the ring. Tokens that collide with one
+// already owned by another node are skipped.
+func (r *hashRing) add(node *Node) {
+	old := slices.Clone(r.tokens)
+	r.members = append(r.members, node)
+	for _, token := range nodeTokens(node.address, tokenCount(node.virtualNodes, node.weight)) {
+		if _, ok := r.owners[token]; ok {
+			continue
+		}
+		r.owners[token] = node
+		r.tokens = append(r.tokens, token)
+	}
+	slices.Sort(r.tokens)
+	r.rebin(old)
+}
+
+// rebin carries measured loads over from the ranges ending at old to the
+// current ranges.
+func (r *hashRing) rebin(old []uint64) {
+	if r.loads != nil {
+		r.loads = rebinLoads(old, r.loads, r.tokens)
+	}
+	r.changed()
+}
+
+// remove takes every token owned by node off the ring.
+func (r *hashRing) remove(node *Node) {
+	old := slices.Clone(r.tokens)
+	r.members = slices.DeleteFunc(r.members, func(member *Node) bool {
+		return member == node
+	})
+	r.tokens = slices.DeleteFunc(r.tokens, func(token uint64) bool {
+		if r.owners[token] != node {
+			return false
+		}
+		delete(r.owners, token)
+		return true
+	})
+	r.rebin(old)
+}
+
+// lookup returns the node with the given address, or nil if it is not on the ring.
+func (r *hashRing) lookup(addr string) *Node {
+	for _, node := range r.members {
+		if node.address == addr {
+			return node
+		}
+	}
+	return nil
+}
+
+// replicas returns up to count distinct nodes to hold the key with the given
+// hash, as chosen by the ring's placement strategy.
+func (r *hashRing) replicas(hash uint64, count int) []*Node {
+	return r.placement.replicas(r, hash, count)
+}
+
+// hashKey returns the hash that places the file with the given key, which
+// is the hash of the key itself unless the placement strategy says otherwise.
+func (r *hashRing) hashKey(key string) uint64 {
+	if h, ok := r.placement.(keyHasher); ok {
+		return h.hashKey(key)
+	}
+	return keyspace.Hash(key)
+}
+
+// keyReplicas returns up to count distinct nodes to hold the file with the
+// given key.
+func (r *hashRing) keyReplicas(key string, count int) []*Node {
+	return r.replicas(r.hashKey(key), count)
+}
+
+// successors returns up to count distinct nodes walking clockwise from hash.
+func (r *hashRing) successors(hash uint64, count int) []*Node {
+	if len(r.tokens) == 0 {
+		return nil
+	}
+	idx := sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i] >= hash
+	})
+	successors := make([]*Node, 0, count)
+	for i := 0; i < len(r.tokens) && len(successors) < count; i++ {
+		node := r.owners[r.tokens[(idx+i)%len(r.tokens)]]
+		if !slices.Contains(successors, node) {
+			successors = append(successors, node)
+		}
+	}
+	return successors
+}
+
+// nodes returns the distinct nodes on the ring in the order they joined.
+func (r *hashRing) nodes() []*Node {
+	return slices.Clone(r.members)
+}
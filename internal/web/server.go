@@ -3,6 +3,7 @@
 package web
 
 import (
+	"errors"
 	"html/template"
 	"io"
 	"log"
@@ -12,9 +13,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
+	"tritontube/internal/safepath"
 )
 
 // 256 MBs
@@ -109,6 +110,10 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "filename of length 0 not allowed", http.StatusBadRequest)
 		return
 	}
+	if err := safepath.CheckName(videoId); err != nil {
+		http.Error(w, "invalid video name", http.StatusBadRequest)
+		return
+	}
 	// Better to check duplicates with read since we could potentially
 	// insert an entry with create but the mp4 file doesn't convert
 	_, err = s.metadataService.Read(videoId)
@@ -122,7 +127,7 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer os.RemoveAll(tempDir)
-	videoPath := filepath.Join(tempDir, head.Filename)
+	videoPath := filepath.Join(tempDir, videoId+".mp4")
 	copy, err := os.Create(videoPath)
 	if err != nil {
 		http.Error(w, "failed to create file", http.StatusInternalServerError)
@@ -175,7 +180,7 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, f := range files {
-		if f.Name() == head.Filename {
+		if f.Name() == videoId+".mp4" {
 			continue
 		}
 		data, err := os.ReadFile(filepath.Join(tempDir, f.Name()))
@@ -183,7 +188,13 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "failed to iterate through files", http.StatusInternalServerError)
 			return
 		}
-		err = s.contentService.Write(videoId, f.Name(), data)
+		// Create rather than Write so a concurrent upload of the same video
+		// can't silently replace these files.
+		err = s.contentService.Create(videoId, f.Name(), data)
+		if errors.Is(err, os.ErrExist) {
+			http.Error(w, "video already exists with name", http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, "failed to copy over files", http.StatusInternalServerError)
 			return
@@ -215,7 +226,7 @@ func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) handleVideoContent(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "not GET request", http.StatusBadRequest)
 		return
 	}
@@ -228,18 +239,33 @@ func (s *server) handleVideoContent(w http.ResponseWriter, r *http.Request) {
 	}
 	videoId = parts[0]
 	filename := parts[1]
+	if err := safepath.Check(videoId, filename); err != nil {
+		http.Error(w, "Invalid content path", http.StatusBadRequest)
+		return
+	}
 	log.Println("Video ID:", videoId, "Filename:", filename)
-	file, err := s.contentService.Read(videoId, filename)
+	info, err := s.contentService.Stat(videoId, filename)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "file does not exist", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to get files", http.StatusInternalServerError)
 		return
 	}
 	if filename == "manifest.mpd" {
-		w.Header().Add("Content-Type", "application/dash+xml")
-		w.Header().Add("Content-Length", strconv.Itoa(len(file)))
+		w.Header().Set("Content-Type", "application/dash+xml")
 	} else {
-		w.Header().Add("Content-Type", "video/m4s")
-		w.Header().Add("Content-Length", strconv.Itoa(len(file)))
+		w.Header().Set("Content-Type", "video/m4s")
 	}
-	w.Write(file)
+	// ServeContent answers Range and conditional requests from the ETag and
+	// modification time, and only reads the bytes it sends.
+	w.Header().Set("ETag", info.ETag)
+	http.ServeContent(w, r, filename, info.ModTime, &contentReader{
+		service:  s.contentService,
+		videoId:  videoId,
+		filename: filename,
+		size:     info.Size,
+		whole:    wholeFileRequest(r.Header.Get("Range"), info.Size),
+	})
 }
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMetadata is a VideoMetadataService that holds no videos.
+type fakeMetadata struct{}
+
+func (fakeMetadata) Read(id string) (*VideoMetadata, error) {
+	return nil, os.ErrNotExist
+}
+
+func (fakeMetadata) List() ([]VideoMetadata, error) {
+	return nil, nil
+}
+
+func (fakeMetadata) Create(videoId string, uploadedAt time.Time) error {
+	return nil
+}
+
+// testServer returns a server storing content under a fresh directory, and
+// that directory's parent, which holds a file no request may reach.
+func testServer(t *testing.T) (*server, string) {
+	t.Helper()
+	base := t.TempDir()
+	fs := &FSVideoContentService{}
+	if err := fs.Initialize(filepath.Join(base, "content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write("video", "manifest.mpd", []byte("manifest")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(fakeMetadata{}, fs), base
+}
+
+func TestHandleVideoContentTraversal(t *testing.T) {
+	s, _ := testServer(t)
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{"valid", "/content/video/manifest.mpd", http.StatusOK},
+		{"missing", "/content/video/missing.m4s", http.StatusNotFound},
+		{"parent video ID", "/content/../secret", http.StatusBadRequest},
+		{"parent filename", "/content/video/..", http.StatusBadRequest},
+		{"escaping", "/content/video/../../secret", http.StatusBadRequest},
+		{"encoded parent", "/content/%2e%2e/secret", http.StatusBadRequest},
+		{"encoded slash", "/content/video/..%2f..%2fsecret", http.StatusBadRequest},
+		{"backslash", `/content/video/..\..\secret`, http.StatusBadRequest},
+		{"dot video ID", "/content/./secret", http.StatusBadRequest},
+		{"empty video ID", "/content//manifest.mpd", http.StatusBadRequest},
+		{"no filename", "/content/video", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleVideoContent(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.code {
+				t.Errorf("GET %s: status %d, want %d", tt.path, w.Code, tt.code)
+			}
+			if strings.Contains(w.Body.String(), "secret") {
+				t.Errorf("GET %s served the file outside the content directory", tt.path)
+			}
+		})
+	}
+}
+
+func TestHandleUploadTraversal(t *testing.T) {
+	s, base := testServer(t)
+	// The multipart reader drops any directory from an uploaded file's
+	// name, so only names that are unsafe as they stand are left to reject.
+	for _, filename := range []string{"...mp4", `..\secret.mp4`, ".hidden.mp4", "a\x00b.mp4"} {
+		t.Run(filename, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, err := mw.CreateFormFile("file", filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte("not a video"))
+			mw.Close()
+			r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			s.handleUpload(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("upload of %q: status %d, want %d", filename, w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "content" && entry.Name() != "secret" {
+			t.Errorf("unexpected %s written", entry.Name())
+		}
+	}
+}
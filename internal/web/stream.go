@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"tritontube/internal/filestream"
+	"tritontube/internal/keyspace"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readFile fetches a whole file from a storage node using ReadFileStream and
+// checks it against the checksum the node sends with it.
+func readFile(ctx context.Context, client proto.StorageServiceClient, videoId string, filename string) ([]byte, error) {
+	data, header, err := readRange(ctx, client, videoId, filename, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if len(header.Checksum) > 0 && !bytes.Equal(header.Checksum, sum[:]) {
+		return nil, status.Errorf(codes.DataLoss, "checksum mismatch for %s/%s", videoId, filename)
+	}
+	return data, nil
+}
+
+// readRange fetches part of a file from a storage node, as described by
+// ReadFileRequest, along with the header describing the whole file.
+func readRange(ctx context.Context, client proto.StorageServiceClient, videoId string, filename string, offset int64, length int64) ([]byte, *proto.ReadFileResponse, error) {
+	stream, err := client.ReadFileStream(ctx, &proto.ReadFileRequest{VideoId: videoId, Filename: filename, Offset: offset, Length: length})
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	var header *proto.ReadFileResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if header == nil {
+			header = resp
+		}
+		buf.Write(resp.Data)
+	}
+	if header == nil {
+		return nil, nil, status.Errorf(codes.Internal, "no response for %s/%s", videoId, filename)
+	}
+	return buf.Bytes(), header, nil
+}
+
+// writeFile stores data on a storage node using WriteFileStream. The node
+// rejects the write if what it received does not match data's checksum and,
+// with createOnly set, if it already holds the file.
+func writeFile(ctx context.Context, client proto.StorageServiceClient, videoId string, filename string, data []byte, createOnly bool) error {
+	stream, err := client.WriteFileStream(ctx)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	w := filestream.NewWriter(stream, &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Checksum: sum[:], CreateOnly: createOnly})
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// copyFile pipes a file from src to dst chunk by chunk without buffering it
+// whole, returning the number of bytes copied. The source's checksum is passed
+// along so dst rejects a copy that was corrupted on the way.
+func copyFile(ctx context.Context, src proto.StorageServiceClient, dst proto.StorageServiceClient, videoId string, filename string) (int64, error) {
+	// Cancelling on return aborts the write stream if the read side fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in, err := src.ReadFileStream(ctx, &proto.ReadFileRequest{VideoId: videoId, Filename: filename})
+	if err != nil {
+		return 0, err
+	}
+	out, err := dst.WriteFileStream(ctx)
+	if err != nil {
+		return 0, err
+	}
+	header := &proto.WriteFileRequest{VideoId: videoId, Filename: filename}
+	var w *filestream.Writer
+	for {
+		resp, err := in.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if w == nil {
+			// The first response carries the checksum of the whole file.
+			header.Checksum = resp.Checksum
+			w = filestream.NewWriter(out, header)
+		}
+		if _, err := w.Write(resp.Data); err != nil {
+			return w.Sent(), err
+		}
+	}
+	if w == nil {
+		w = filestream.NewWriter(out, header)
+	}
+	return w.Sent(), w.Close()
+}
+
+// transferFile has src push a file straight to dst, so its data does not pass
+// through this process, returning the number of bytes copied. Nodes that
+// predate TransferFiles have the file piped through copyFile instead.
+func transferFile(ctx context.Context, src *Node, dst *Node, videoId string, filename string) (int64, error) {
+	resp, err := src.client.TransferFiles(ctx, &proto.TransferFilesRequest{
+		Destination: dst.address,
+		Filenames:   []string{keyspace.Key(videoId, filename)},
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return copyFile(ctx, src.client, dst.client, videoId, filename)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Results) != 1 {
+		return 0, fmt.Errorf("transfer of %s/%s returned %d results", videoId, filename, len(resp.Results))
+	}
+	if result := resp.Results[0]; result.Error != "" {
+		return 0, errors.New(result.Error)
+	}
+	return resp.Results[0].Bytes, nil
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"context"
+	"sync"
+	"time"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// migrationThrottle bounds how many files migrations move at once and how
+// fast they move them. Both limits can change while a migration runs.
+type migrationThrottle struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	workers int
+	active  int
+	// bytesPerSecond caps migration traffic; 0 means no cap. next is the
+	// earliest time the next file may start without exceeding the cap.
+	bytesPerSecond int64
+	next           time.Time
+}
+
+func newMigrationThrottle(workers int, bytesPerSecond int64) *migrationThrottle {
+	t := &migrationThrottle{workers: workers, bytesPerSecond: bytesPerSecond}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// acquire blocks until fewer than workers files are being moved and claims a
+// slot for one more.
+func (t *migrationThrottle) acquire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.active >= t.workers {
+		t.cond.Wait()
+	}
+	t.active += 1
+}
+
+func (t *migrationThrottle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active -= 1
+	t.cond.Broadcast()
+}
+
+// pace blocks until size more bytes can be moved under the bandwidth cap.
+// Files are copied by the storage nodes themselves, so traffic is paced a
+// whole file at a time: each file starts once the files before it would have
+// finished at the capped rate.
+func (t *migrationThrottle) pace(size int64) {
+	t.mu.Lock()
+	if t.bytesPerSecond <= 0 {
+		t.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(time.Duration(float64(size) / float64(t.bytesPerSecond) * float64(time.Second)))
+	t.mu.Unlock()
+	time.Sleep(start.Sub(now))
+}
+
+// limits returns the limits in effect.
+func (t *migrationThrottle) limits() (int, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.workers, t.bytesPerSecond
+}
+
+func (t *migrationThrottle) setWorkers(workers int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.workers = workers
+	t.cond.Broadcast()
+}
+
+func (t *migrationThrottle) setBytesPerSecond(bytesPerSecond int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bytesPerSecond = bytesPerSecond
+	// Time owed under the old cap is forgiven so the new one applies at once.
+	t.next = time.Time{}
+}
+
+// SetMigrationLimits changes the number of files migrations move at once and
+// the cap on their traffic. Moves already under way are not interrupted.
+func (n *NetworkVideoContentService) SetMigrationLimits(ctx context.Context, req *proto.SetMigrationLimitsRequest) (*proto.SetMigrationLimitsResponse, error) {
+	if req.Workers != nil && req.GetWorkers() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "workers must be at least 1")
+	}
+	if req.BytesPerSecond != nil && req.GetBytesPerSecond() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "bytes per second must not be negative")
+	}
+	if req.Workers != nil {
+		n.throttle.setWorkers(int(req.GetWorkers()))
+	}
+	if req.BytesPerSecond != nil {
+		n.throttle.setBytesPerSecond(req.GetBytesPerSecond())
+	}
+	workers, bytesPerSecond := n.throttle.limits()
+	return &proto.SetMigrationLimitsResponse{Workers: int32(workers), BytesPerSecond: bytesPerSecond}, nil
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"slices"
+	"strings"
+	"tritontube/internal/proto"
+)
+
+// nodeLabels are the failure domains a node belongs to. Empty labels are
+// unknown and never count as shared with another node.
+type nodeLabels struct {
+	Zone string `json:"zone,omitempty"`
+	Rack string `json:"rack,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+func labelsFromProto(labels *proto.NodeLabels) nodeLabels {
+	return nodeLabels{Zone: labels.GetZone(), Rack: labels.GetRack(), Host: labels.GetHost()}
+}
+
+func (l nodeLabels) proto() *proto.NodeLabels {
+	return &proto.NodeLabels{Zone: l.Zone, Rack: l.Rack, Host: l.Host}
+}
+
+func (l nodeLabels) empty() bool {
+	return l == nodeLabels{}
+}
+
+// domain returns the failure domain of the node at the given level: 0 for
+// its zone, 1 for its rack within the zone and 2 for its host within the
+// rack. It returns "" if the label for that level is unknown.
+func (l nodeLabels) domain(level int) string {
+	labels := []string{l.Zone, l.Rack, l.Host}
+	if labels[level] == "" {
+		return ""
+	}
+	return strings.Join(labels[:level+1], "/")
+}
+
+// labeled reports whether any node on the ring has labels.
+func (r *hashRing) labeled() bool {
+	return slices.ContainsFunc(r.members, func(node *Node) bool {
+		return !node.labels.empty()
+	})
+}
+
+// pick returns count nodes from the preference order returned by prefs,
+// which is called with how many nodes it must order. When nodes are labeled,
+// every node is ordered and the replicas are spread across failure domains.
+func (r *hashRing) pick(count int, prefs func(n int) []*Node) []*Node {
+	if !r.labeled() {
+		return prefs(count)
+	}
+	return spreadReplicas(prefs(len(r.members)), count)
+}
+
+// spreadReplicas picks count nodes from prefs, most preferred first, taking
+// nodes in zones no chosen node is in while there are any, then nodes on new
+// racks, then on new hosts, and finally any node.
+func spreadReplicas(prefs []*Node, count int) []*Node {
+	count = min(count, len(prefs))
+	chosen := make([]*Node, 0, count)
+	for level := 0; level <= 3 && len(chosen) < count; level++ {
+		for _, node := range prefs {
+			if len(chosen) == count {
+				break
+			}
+			if slices.Contains(chosen, node) {
+				continue
+			}
+			if level < 3 && sharesDomain(node, chosen, level) {
+				continue
+			}
+			chosen = append(chosen, node)
+		}
+	}
+	// Keep the replicas in preference order, so the first is the one the
+	// strategy prefers most.
+	slices.SortStableFunc(chosen, func(a, b *Node) int {
+		return slices.Index(prefs, a) - slices.Index(prefs, b)
+	})
+	return chosen
+}
+
+// sharesDomain reports whether node is in the same failure domain at level
+// as any of nodes.
+func sharesDomain(node *Node, nodes []*Node, level int) bool {
+	domain := node.labels.domain(level)
+	if domain == "" {
+		return false
+	}
+	return slices.ContainsFunc(nodes, func(other *Node) bool {
+		return other.labels.domain(level) == domain
+	})
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// labeledNodes returns a node for each "zone/rack/host" label, addressed by
+// its position. Missing or empty parts are unknown.
+func labeledNodes(labels ...string) []*Node {
+	nodes := make([]*Node, len(labels))
+	for i, label := range labels {
+		parts := append(strings.Split(label, "/"), "", "", "")
+		nodes[i] = &Node{
+			address:      fmt.Sprintf("n%d", i),
+			virtualNodes: 16,
+			weight:       1,
+			labels:       nodeLabels{Zone: parts[0], Rack: parts[1], Host: parts[2]},
+		}
+	}
+	return nodes
+}
+
+func TestSpreadReplicas(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		count  int
+		// want are the addresses picked, in order, from nodes preferred in
+		// the order given.
+		want []string
+	}{
+		{"zones", []string{"a/1/x", "a/1/y", "b/1/x", "c/1/x"}, 3, []string{"n0", "n2", "n3"}},
+		{"fewer zones than replicas", []string{"a/1/x", "a/1/y", "b/1/x", "b/2/x"}, 3, []string{"n0", "n2", "n3"}},
+		{"racks within a zone", []string{"a/1/x", "a/1/y", "a/2/x", "a/3/x"}, 3, []string{"n0", "n2", "n3"}},
+		{"hosts within a rack", []string{"a/1/x", "a/1/x", "a/1/y", "a/1/z"}, 2, []string{"n0", "n2"}},
+		{"same host", []string{"a/1/x", "a/1/x", "a/1/x"}, 2, []string{"n0", "n1"}},
+		{"new zone before new rack", []string{"a/1/x", "a/2/x", "b/1/x"}, 2, []string{"n0", "n2"}},
+		{"kept in preference order", []string{"a/1/x", "a/1/y", "b/1/x"}, 3, []string{"n0", "n1", "n2"}},
+		{"unlabeled nodes share nothing", []string{"", "", "a/1/x"}, 2, []string{"n0", "n1"}},
+		{"unknown racks", []string{"a", "a", "b"}, 3, []string{"n0", "n1", "n2"}},
+		{"more replicas than nodes", []string{"a/1/x", "b/1/x"}, 3, []string{"n0", "n1"}},
+		{"one replica", []string{"a/1/x", "b/1/x"}, 1, []string{"n0"}},
+		{"no nodes", nil, 2, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeAddresses(spreadReplicas(labeledNodes(tt.labels...), tt.count))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("spreadReplicas = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPickSpreadsZones checks that every strategy spreads replicas across
+// zones once nodes are labeled.
+func TestPickSpreadsZones(t *testing.T) {
+	nodes := labeledNodes("a/1/x", "a/1/y", "a/2/x", "b/1/x", "b/1/y", "c/1/x")
+	for _, p := range []PlacementStrategy{ringPlacement{}, rendezvousPlacement{}, jumpPlacement{}} {
+		t.Run(p.Name(), func(t *testing.T) {
+			r := placementRing(p, nodes...)
+			for i := range 1000 {
+				key := fmt.Sprint(i)
+				replicas := r.keyReplicas(key, 3)
+				zones := make(map[string]bool)
+				for _, node := range replicas {
+					zones[node.labels.Zone] = true
+				}
+				if len(replicas) != 3 || len(zones) != 3 {
+					t.Fatalf("replicas of %s are %v, want one in each of 3 zones", key, nodeAddresses(replicas))
+				}
+			}
+		})
+	}
+}